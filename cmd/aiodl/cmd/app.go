@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/internal/archive"
+	"github.com/aiomayo/aiodl/internal/events"
+	"github.com/aiomayo/aiodl/internal/outfile"
+	"github.com/aiomayo/aiodl/internal/outtmpl"
+	"github.com/aiomayo/aiodl/internal/scheduler"
+	"github.com/aiomayo/aiodl/internal/tui/views"
+)
+
+type appBackend struct {
+	arch *archive.Archive
+	wg   sync.WaitGroup
+}
+
+func runApp(parent context.Context) error {
+	arch, err := openArchive("", false)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	b := &appBackend{arch: arch}
+	app := views.NewApp(ctx, views.AppBackend{
+		Fetch:      b.fetch,
+		OutputPath: b.outputPath,
+		Download:   b.download,
+	}).WithPreview(newPreview())
+
+	log.SetOutput(io.Discard)
+	_, err = ui.Run(parent, app)
+	cancel()
+	b.wg.Wait()
+	log.SetOutput(os.Stderr)
+
+	if err != nil {
+		return fmt.Errorf("app failed: %w", err)
+	}
+	return nil
+}
+
+func (b *appBackend) fetch(ctx context.Context, url string) (*adapter.MediaInfo, error) {
+	adp, found := adapter.Find(url)
+	if !found {
+		return nil, fmt.Errorf("no adapter for URL: %s", url)
+	}
+	info, err := adp.GetInfo(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media info: %w", err)
+	}
+	return info, nil
+}
+
+func (b *appBackend) outputPath(info *adapter.MediaInfo, format, audio *adapter.Format) string {
+	adp, found := adapter.Find(info.URL)
+	if !found {
+		return ""
+	}
+
+	if info.Type == adapter.MediaTypePlaylist {
+		src := templateSource(adp, true)
+		if !filepath.IsAbs(src) && cfg.DownloadDir != "" {
+			src = filepath.Join(cfg.DownloadDir, src)
+		}
+		if abs, err := filepath.Abs(src); err == nil {
+			src = abs
+		}
+		return src
+	}
+
+	tmpl, err := outputTemplate(adp, "", false)
+	if err != nil {
+		return ""
+	}
+	opts, sel, err := chooseFormats(info, selectedFormats(adapter.DownloadOptions{}, format, audio))
+	if err != nil {
+		return ""
+	}
+	return resolveOutputPath(tmpl, outtmpl.InfoFields(info), sel, opts)
+}
+
+func (b *appBackend) download(ctx context.Context, req views.DownloadRequest, send func(tea.Msg)) tea.Model {
+	ctx, cancel := context.WithCancel(ctx)
+
+	adp, found := adapter.Find(req.Info.URL)
+	if !found {
+		return b.failed(cancel, req.Output, fmt.Errorf("no adapter for URL: %s", req.Info.URL), send)
+	}
+	if req.Info.Type == adapter.MediaTypePlaylist {
+		return b.downloadPlaylist(ctx, cancel, adp, req, send)
+	}
+
+	opts, sel, err := chooseFormats(req.Info, selectedFormats(adapter.DownloadOptions{}, req.Format, req.Audio))
+	if err != nil {
+		return b.failed(cancel, req.Output, err, send)
+	}
+
+	tr := newTracker(req.Info.URL, func(e events.Event) { send(e) })
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer cancel()
+
+		tr.start()
+		tr.info(req.Info)
+		tr.format(sel, opts)
+		path, size, err := downloadVerified(ctx, adp, req.Info, opts, req.Output, tr)
+		if err == nil || errors.Is(err, outfile.ErrExists) {
+			recordDownload(b.arch, adp, req.Info)
+		}
+		tr.finish(path, size, err)
+	}()
+
+	return views.NewDownloadProgress(req.Output).WithCancel(cancel).Embedded()
+}
+
+func (b *appBackend) downloadPlaylist(ctx context.Context, cancel context.CancelFunc, adp adapter.Adapter,
+	req views.DownloadRequest, send func(tea.Msg)) tea.Model {
+
+	tmpl, err := outtmpl.Parse(req.Output)
+	if err != nil {
+		return b.failed(cancel, req.Output, err, send)
+	}
+
+	q := newDownloadQueue(b.arch, 0)
+	q.addPlaylist(adp, req.Info, req.Items, adapter.DownloadOptions{}, tmpl, nil)
+	if len(q.items) == 0 {
+		return b.failed(cancel, req.Info.Title, errors.New("all selected videos are already downloaded"), send)
+	}
+
+	pool := scheduler.New(cfg.Parallel)
+	ctl := newQueueControl(len(q.items))
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer cancel()
+		q.processDashboard(ctx, pool, ctl, send)
+	}()
+
+	return q.dashboard(pool, ctl, cancel).Embedded()
+}
+
+func (b *appBackend) failed(cancel context.CancelFunc, name string, err error, send func(tea.Msg)) tea.Model {
+	cancel()
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		newTracker("", func(e events.Event) { send(e) }).finish("", 0, err)
+	}()
+	return views.NewDownloadProgress(name).Embedded()
+}
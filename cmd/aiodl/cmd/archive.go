@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+
+	"github.com/aiomayo/aiodl/internal/archive"
+)
+
+func newArchiveCmd() *cobra.Command {
+	var archivePath string
+
+	cmd := &cobra.Command{
+		Use:   "archive",
+		Short: "Manage the download archive",
+	}
+	cmd.PersistentFlags().StringVar(&archivePath, "download-archive", "", "archive file (default from config)")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "path",
+		Short: "Show the archive file path",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := archivePath
+			if path == "" {
+				path = cfg.DownloadArchive
+			}
+			arch, err := archive.Open(path)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(os.Stdout, arch.Path())
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "import [FILE]",
+		Short: "Import a yt-dlp download archive",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer func() { _ = src.Close() }()
+
+			path := archivePath
+			if path == "" {
+				path = cfg.DownloadArchive
+			}
+			arch, err := archive.Open(path)
+			if err != nil {
+				return err
+			}
+
+			added, err := arch.Import(src)
+			if err != nil {
+				return fmt.Errorf("import %q: %w", args[0], err)
+			}
+			log.Info("Imported archive", "added", added, "total", arch.Len(), "archive", arch.Path())
+			return nil
+		},
+	})
+
+	return cmd
+}
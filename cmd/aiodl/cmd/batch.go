@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/internal/filter"
+	"github.com/aiomayo/aiodl/internal/outtmpl"
+	"github.com/aiomayo/aiodl/selector"
+)
+
+type batchJob struct {
+	URL     string `json:"url"`
+	Format  string `json:"format,omitempty"`
+	Output  string `json:"output,omitempty"`
+	Quality string `json:"quality,omitempty"`
+}
+
+func (j batchJob) validate() error {
+	if j.URL == "" {
+		return fmt.Errorf("missing url")
+	}
+	if j.Format != "" {
+		if _, err := selector.Parse(j.Format); err != nil {
+			return err
+		}
+	}
+	if j.Quality != "" {
+		if _, err := selector.ParseQuality(j.Quality); err != nil {
+			return err
+		}
+	}
+	if j.Output != "" {
+		if _, err := outtmpl.Parse(j.Output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type jobSettings struct {
+	opts           adapter.DownloadOptions
+	filter         *filter.Filter
+	output         string
+	playlistOutput string
+}
+
+func (s jobSettings) withOverrides(job batchJob) jobSettings {
+	if job.Format != "" {
+		s.opts.FormatSelector = job.Format
+	}
+	if job.Quality != "" {
+		s.opts.Quality = job.Quality
+	}
+	if job.Output != "" {
+		s.output, s.playlistOutput = job.Output, job.Output
+	}
+	return s
+}
+
+func (s jobSettings) templates(adp adapter.Adapter) (*outtmpl.Template, *outtmpl.Template, error) {
+	tmpl, err := outputTemplate(adp, s.output, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	playlistOutput := s.playlistOutput
+	if playlistOutput == "" {
+		playlistOutput = s.output
+	}
+	playlistTmpl, err := outputTemplate(adp, playlistOutput, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tmpl, playlistTmpl, nil
+}
+
+func collectJobs(urls []string, batchFile, batchJSON string) ([]batchJob, error) {
+	var jobs []batchJob
+	for _, url := range urls {
+		jobs = append(jobs, batchJob{URL: url})
+	}
+
+	if strings.Contains(batchFile, "://") {
+		return nil, fmt.Errorf("-a/--batch-file expects a file of URLs, got URL %q (audio only is now -x/--audio-only)", batchFile)
+	}
+	if batchFile != "" {
+		err := readBatch(batchFile, func(n int, line string) error {
+			if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+				return nil
+			}
+			jobs = append(jobs, batchJob{URL: line})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if batchJSON != "" {
+		err := readBatch(batchJSON, func(n int, line string) error {
+			if line = strings.TrimSpace(line); line == "" {
+				return nil
+			}
+			var job batchJob
+			if err := json.Unmarshal([]byte(line), &job); err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			if err := job.validate(); err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			jobs = append(jobs, job)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return jobs, nil
+}
+
+func readBatch(path string, fn func(n int, line string) error) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("batch file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if err := fn(n, scanner.Text()); err != nil {
+			return fmt.Errorf("batch %s: %w", path, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("batch %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+
+	"github.com/aiomayo/aiodl/internal/events"
+	"github.com/aiomayo/aiodl/internal/outfile"
+	"github.com/aiomayo/aiodl/internal/scheduler"
+	"github.com/aiomayo/aiodl/internal/tui/views"
+)
+
+var (
+	errItemSkipped   = errors.New("skipped")
+	errItemCancelled = errors.New("cancelled")
+)
+
+type itemStatus int
+
+const (
+	statusPending itemStatus = iota
+	statusDownloaded
+	statusFiltered
+	statusExisting
+	statusSkipped
+	statusFailed
+)
+
+type queueControl struct {
+	mu        sync.Mutex
+	status    []itemStatus
+	skip      []bool
+	cancelled []bool
+	errs      []error
+	cancels   map[int]context.CancelFunc
+	wake      chan struct{}
+}
+
+func newQueueControl(n int) *queueControl {
+	return &queueControl{
+		status:    make([]itemStatus, n),
+		skip:      make([]bool, n),
+		cancelled: make([]bool, n),
+		errs:      make([]error, n),
+		cancels:   make(map[int]context.CancelFunc),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+func (c *queueControl) start(ctx context.Context, i int) (context.Context, context.CancelFunc, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.skip[i]:
+		return nil, nil, errItemSkipped
+	case c.cancelled[i]:
+		return nil, nil, errItemCancelled
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancels[i] = cancel
+	return ctx, cancel, nil
+}
+
+func (c *queueControl) finish(i int, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cancels, i)
+	switch {
+	case c.skip[i]:
+		return errItemSkipped
+	case c.cancelled[i] && errors.Is(err, context.Canceled):
+		return errItemCancelled
+	}
+	return err
+}
+
+func (c *queueControl) cancel(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelled[i] = true
+	if cancel, ok := c.cancels[i]; ok {
+		cancel()
+	}
+}
+
+func (c *queueControl) skipItem(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.skip[i] = true
+	if cancel, ok := c.cancels[i]; ok {
+		cancel()
+	}
+}
+
+func (c *queueControl) retry(i int) {
+	c.mu.Lock()
+	if c.status[i] == statusFailed {
+		c.status[i] = statusPending
+		c.cancelled[i] = false
+	}
+	c.mu.Unlock()
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *queueControl) set(i int, status itemStatus, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status[i] = status
+	c.errs[i] = err
+}
+
+func (c *queueControl) pending() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var pending []int
+	for i, s := range c.status {
+		if s == statusPending {
+			pending = append(pending, i)
+		}
+	}
+	return pending
+}
+
+func (c *queueControl) count(status itemStatus) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, s := range c.status {
+		if s == status {
+			n++
+		}
+	}
+	return n
+}
+
+func (q *downloadQueue) runDashboard(parent context.Context, pool *scheduler.Pool) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	total := len(q.items)
+	ctl := newQueueControl(total)
+	program := ui.Program(parent, q.dashboard(pool, ctl, cancel))
+
+	log.SetOutput(io.Discard)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.processDashboard(ctx, pool, ctl, program.Send)
+	}()
+
+	_, err := program.Run()
+	cancel()
+	<-done
+	log.SetOutput(os.Stderr)
+
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("dashboard failed: %w", err)
+	}
+
+	downloaded := ctl.count(statusDownloaded)
+	if remaining := ctl.count(statusPending); remaining > 0 {
+		log.Warn("Download cancelled", "downloaded", downloaded, "remaining", remaining)
+		return nil
+	}
+	return q.summarize(total, downloaded, ctl.count(statusFiltered), ctl.count(statusExisting),
+		ctl.count(statusSkipped), errors.Join(ctl.errs...))
+}
+
+func (q *downloadQueue) dashboard(pool *scheduler.Pool, ctl *queueControl, quit func()) views.DashboardModel {
+	titles := make([]string, len(q.items))
+	for i, item := range q.items {
+		titles[i] = item.title()
+	}
+	return views.NewDashboard(fmt.Sprintf("Downloading %d items (%d parallel)", len(q.items), pool.Workers()), titles).
+		WithActions(views.DashboardActions{Cancel: ctl.cancel, Retry: ctl.retry, Skip: ctl.skipItem, Quit: quit})
+}
+
+func (q *downloadQueue) processDashboard(ctx context.Context, pool *scheduler.Pool, ctl *queueControl, send func(tea.Msg)) {
+	trackers := make([]*tracker, len(q.items))
+	for i := range q.items {
+		trackers[i] = q.tracker(i, func(e events.Event) { send(e) })
+	}
+
+	for {
+		pending := ctl.pending()
+		if len(pending) == 0 || ctx.Err() != nil {
+			send(views.DashboardDoneMsg{})
+			select {
+			case <-ctl.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		tasks := make([]scheduler.Task, len(pending))
+		for n, i := range pending {
+			item := q.items[i]
+			tasks[n] = scheduler.Task{
+				Name: item.title(),
+				Run: func(ctx context.Context) error {
+					return q.runDashboardItem(ctx, ctl, i, trackers[i])
+				},
+			}
+		}
+		_ = pool.Run(ctx, tasks, nil)
+	}
+}
+
+func (q *downloadQueue) runDashboardItem(ctx context.Context, ctl *queueControl, i int, tr *tracker) error {
+	item := q.items[i]
+	out := &itemOutcome{tracker: tr}
+
+	itemCtx, cancel, err := ctl.start(ctx, i)
+	if err == nil {
+		err = downloadQueueItem(itemCtx, item, out)
+		cancel()
+		err = ctl.finish(i, err)
+	}
+
+	switch {
+	case errors.Is(err, errItemSkipped):
+		ctl.set(i, statusSkipped, nil)
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		tr.emit(events.New(events.TypeQueued))
+		return err
+	case errors.Is(err, errFiltered):
+		ctl.set(i, statusFiltered, nil)
+	case errors.Is(err, outfile.ErrExists):
+		ctl.set(i, statusExisting, nil)
+		recordDownload(q.arch, item.adp, out.info)
+	case err != nil:
+		ctl.set(i, statusFailed, fmt.Errorf("%s: %w", item.title(), err))
+	default:
+		ctl.set(i, statusDownloaded, nil)
+		recordDownload(q.arch, item.adp, out.info)
+	}
+	tr.finish(out.path, out.size, err)
+	return err
+}
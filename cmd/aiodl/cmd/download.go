@@ -2,216 +2,842 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 
 	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/internal/archive"
+	"github.com/aiomayo/aiodl/internal/events"
+	"github.com/aiomayo/aiodl/internal/filter"
+	"github.com/aiomayo/aiodl/internal/outfile"
+	"github.com/aiomayo/aiodl/internal/outtmpl"
+	"github.com/aiomayo/aiodl/internal/sniff"
 	"github.com/aiomayo/aiodl/internal/tui"
 	"github.com/aiomayo/aiodl/internal/tui/views"
+	"github.com/aiomayo/aiodl/internal/verify"
+	"github.com/aiomayo/aiodl/selector"
 )
 
 func newDownloadCmd() *cobra.Command {
 	var (
-		format      string
-		output      string
-		quality     string
-		audioOnly   bool
-		interactive bool
+		format         string
+		formatSort     string
+		output         string
+		playlistOutput string
+		quality        string
+		audioOnly      bool
+		interactive    bool
+		section        string
+		archivePath    string
+		noArchive      bool
+		parallel       int
+		batchFile      string
+		batchJSON      string
+		filterOpts     filter.Options
+		noVerify       bool
+		checksum       bool
+		retries        int
+		noOverwrites   bool
+		forceOverwrite bool
+		autoRename     bool
+		getURL         bool
+		printTmpl      string
+		progress       string
+		eventsFD       int
+		keepPartial    bool
 	)
 
 	cmd := &cobra.Command{
-		Use:   "download [URL]",
-		Short: "Download media from a URL",
-		Args:  cobra.ExactArgs(1),
+		Use:   "download [URL...]",
+		Short: "Download media from one or more URLs",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			url := args[0]
-			ctx := context.Background()
+			ctx := cmd.Context()
 
-			adp, found := adapter.Find(url)
-			if !found {
-				return fmt.Errorf("no adapter for URL: %s", url)
+			if cmd.Flags().Changed("parallel") {
+				cfg.Parallel = parallel
 			}
-
-			info, err := adp.GetInfo(ctx, url)
+			if noVerify {
+				cfg.Verify = false
+			}
+			if cmd.Flags().Changed("checksum") {
+				cfg.Checksum = checksum
+			}
+			if cmd.Flags().Changed("retries") {
+				cfg.Retries = retries
+			}
+			if cmd.Flags().Changed("keep-partial") {
+				cfg.KeepPartial = keepPartial
+			}
+			switch {
+			case noOverwrites:
+				cfg.Overwrites = string(outfile.Skip)
+			case forceOverwrite:
+				cfg.Overwrites = string(outfile.Force)
+			case autoRename:
+				cfg.Overwrites = string(outfile.Rename)
+			}
+			policy, err := outfile.ParsePolicy(cfg.Overwrites)
 			if err != nil {
-				return fmt.Errorf("failed to get media info: %w", err)
+				return err
 			}
+			cfg.Overwrites = string(policy)
 
-			if info.Type == adapter.MediaTypePlaylist {
-				return runPlaylistDownload(ctx, adp, info, quality, audioOnly)
+			start, end, err := parseSection(section)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("quality") {
+				quality = cfg.Quality
+			}
+			if err := validateSelection(format, formatSort, quality); err != nil {
+				return err
 			}
 
-			var selectedFormat *adapter.Format
-			if format == "" && (interactive || ui.IsInteractive()) && len(info.Formats) > 0 {
-				selector := views.NewFormatSelector(
-					fmt.Sprintf("Select format for: %s", info.Title),
-					info.Formats,
-				)
-
-				model, err := ui.Run(selector)
-				if err != nil {
-					return fmt.Errorf("format selection failed: %w", err)
+			jobs, err := collectJobs(args, batchFile, batchJSON)
+			if err != nil {
+				return err
+			}
+			if len(jobs) == 0 {
+				return errors.New("no URLs given (pass URLs, --batch-file or --batch-json)")
+			}
+			if output == stdoutPath {
+				if len(jobs) > 1 {
+					return errors.New("writing to stdout (-o -) needs a single URL")
 				}
+				ui = tui.NewWithMode(tui.ModeNonInteractive)
+				log.SetOutput(os.Stderr)
+				interactive = false
+			}
 
-				result := model.(views.FormatSelectorModel)
-				if result.Cancelled() {
-					log.Warn("Download cancelled")
-					return nil
-				}
-				selectedFormat = result.Selected()
+			mode, err := parseProgressMode(progress)
+			if err != nil {
+				return err
 			}
+			if mode == progressJSON && (output == stdoutPath || getURL || printTmpl != "") {
+				return errors.New("--progress=json writes events to stdout; use --events-fd instead")
+			}
+			if eventSink, err = openEventSinks(mode, eventsFD); err != nil {
+				return err
+			}
+			if mode != progressAuto {
+				ui = tui.NewWithMode(tui.ModeNonInteractive)
+				interactive = false
+			}
+			progressKind = mode
 
-			downloadOpts := adapter.DownloadOptions{
-				Quality:   quality,
-				FormatID:  format,
-				AudioOnly: audioOnly,
+			if !cmd.Flags().Changed("match-filter") {
+				filterOpts.MatchFilter = cfg.MatchFilter
+			}
+			itemFilter, err := filter.New(filterOpts)
+			if err != nil {
+				return err
 			}
 
-			if selectedFormat != nil {
-				downloadOpts.FormatID = selectedFormat.ID
+			settings := jobSettings{
+				opts: adapter.DownloadOptions{
+					Quality:        quality,
+					FormatSelector: format,
+					FormatSort:     formatSort,
+					AudioOnly:      audioOnly,
+					Container:      cfg.Container(),
+					Start:          start,
+					End:            end,
+				},
+				filter:         itemFilter,
+				output:         output,
+				playlistOutput: playlistOutput,
 			}
 
-			outputPath := output
-			if outputPath == "" {
-				ext := "mp4"
-				if audioOnly {
-					ext = "m4a"
-				}
-				if selectedFormat != nil && selectedFormat.Extension != "" {
-					ext = selectedFormat.Extension
+			if getURL || printTmpl != "" {
+				p := &printer{w: os.Stdout}
+				if printTmpl != "" {
+					if p.tmpl, err = parsePrintTemplate(printTmpl); err != nil {
+						return err
+					}
 				}
-				outputPath = sanitizeFilename(info.Title) + "." + ext
+				return runPrint(ctx, jobs, settings, p)
+			}
+
+			arch, err := openArchive(archivePath, noArchive)
+			if err != nil {
+				return err
 			}
 
-			if ui.IsInteractive() {
-				return runInteractiveDownload(ctx, adp, info, downloadOpts, outputPath)
+			if output == stdoutPath || len(jobs) == 1 && batchFile == "" && batchJSON == "" {
+				return runSingleDownload(ctx, jobs[0].URL, settings, arch, interactive)
 			}
-			return runNonInteractiveDownload(ctx, adp, info, downloadOpts, outputPath)
+			return runBatchDownload(ctx, jobs, settings, arch)
 		},
 	}
 
-	cmd.Flags().StringVarP(&format, "format", "f", "", "format ID (itag)")
-	cmd.Flags().StringVarP(&output, "output", "o", "", "output path")
-	cmd.Flags().StringVarP(&quality, "quality", "q", "", "quality (e.g., 720p, 1080p)")
-	cmd.Flags().BoolVarP(&audioOnly, "audio-only", "a", false, "audio only")
+	cmd.Flags().StringVarP(&format, "format", "f", "", "format ID (itag) or selector (e.g., bv[height<=1080]+ba/b)")
+	cmd.Flags().StringVarP(&formatSort, "format-sort", "S", "", "sort order for best/worst (e.g., res,fps,codec:av1,size)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output template, relative to download_dir (e.g., \"{uploader}/{title} [{id}].{ext}\"), or - for stdout")
+	cmd.Flags().StringVar(&playlistOutput, "playlist-output", "", "output template for playlist items (default from config)")
+	cmd.Flags().StringVarP(&quality, "quality", "q", "", "quality: best, worst, audio, 1080p (nearest lower) or <=1080p (default from config)")
+	cmd.Flags().BoolVarP(&audioOnly, "audio-only", "x", false, "audio only")
+	cmd.Flags().StringVarP(&batchFile, "batch-file", "a", "", "file with one URL per line, '#' for comments, '-' for stdin")
+	cmd.Flags().StringVar(&batchJSON, "batch-json", "", "JSON lines file with url and optional format, output, quality ('-' for stdin)")
 	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "interactively select format")
+	cmd.Flags().IntVarP(&parallel, "parallel", "N", 0, "concurrent playlist downloads (default from config)")
+	cmd.Flags().StringVar(&archivePath, "download-archive", "", "archive file of downloaded IDs (default from config)")
+	cmd.Flags().BoolVar(&noArchive, "no-download-archive", false, "do not read or update the download archive")
+	cmd.Flags().BoolVar(&noVerify, "no-verify", false, "skip size and container checks after download")
+	cmd.Flags().BoolVar(&checksum, "checksum", false, "write a SHA-256 sidecar next to each file (default from config)")
+	cmd.Flags().IntVar(&retries, "retries", 0, "retries for truncated or corrupt downloads (default from config)")
+	cmd.Flags().BoolVar(&keepPartial, "keep-partial", false, "keep .part files of interrupted or failed downloads so the next run resumes them (default from config)")
+	cmd.Flags().BoolVar(&noOverwrites, "no-overwrites", false, "skip files that already exist (default)")
+	cmd.Flags().BoolVar(&forceOverwrite, "force-overwrites", false, "overwrite existing files")
+	cmd.Flags().BoolVar(&autoRename, "auto-rename", false, "add a \" (1)\" style suffix instead of overwriting existing files")
+	cmd.MarkFlagsMutuallyExclusive("no-overwrites", "force-overwrites", "auto-rename")
+	cmd.Flags().BoolVarP(&getURL, "get-url", "g", false, "print the direct URLs of the selected formats instead of downloading")
+	cmd.Flags().StringVar(&printTmpl, "print", "", "print a template for each item instead of downloading (e.g., \"{id}\\t{title}\\t{filename}\")")
+	cmd.MarkFlagsMutuallyExclusive("get-url", "print")
+	cmd.Flags().StringVar(&progress, "progress", string(progressAuto), "progress output: auto, plain (no TUI) or json (newline-delimited events on stdout)")
+	cmd.Flags().IntVar(&eventsFD, "events-fd", 0, "also write newline-delimited JSON events to this file descriptor")
+	cmd.Flags().StringVar(&section, "section", "", "download only a time range (e.g., 1:02:00-1:04:30)")
+	cmd.Flags().StringVar(&filterOpts.Items, "items", "", "playlist items to download (e.g., 1-10,15,-5: or 2:20:2)")
+	cmd.Flags().BoolVar(&filterOpts.Reverse, "reverse", false, "download playlist items in reverse order")
+	cmd.Flags().StringVar(&filterOpts.DateAfter, "date-after", "", "only videos uploaded on or after this date (YYYYMMDD)")
+	cmd.Flags().StringVar(&filterOpts.DateBefore, "date-before", "", "only videos uploaded on or before this date (YYYYMMDD)")
+	cmd.Flags().StringVar(&filterOpts.MinDuration, "min-duration", "", "skip videos shorter than this (e.g., 90, 1:30 or 1m30s)")
+	cmd.Flags().StringVar(&filterOpts.MaxDuration, "max-duration", "", "skip videos longer than this (e.g., 10:00 or 10m)")
+	cmd.Flags().StringVar(&filterOpts.MatchTitle, "match-title", "", "only videos whose title matches this regex (case-insensitive)")
+	cmd.Flags().StringVar(&filterOpts.RejectTitle, "reject-title", "", "skip videos whose title matches this regex (case-insensitive)")
+	cmd.Flags().StringVar(&filterOpts.MatchFilter, "match-filter", "", "only videos matching this expression, see 'aiodl fields' (e.g., \"duration > 60 && !is_live\")")
+	cmd.Flags().IntVar(&filterOpts.MaxDownloads, "max-downloads", 0, "stop after queueing this many downloads")
 
 	return cmd
 }
 
-func runInteractiveDownload(ctx context.Context, adp adapter.Adapter,
-	info *adapter.MediaInfo, opts adapter.DownloadOptions, outputPath string) error {
+func runSingleDownload(ctx context.Context, url string, settings jobSettings, arch *archive.Archive, interactive bool) error {
+	tr := newTracker(url, nil)
+	err := downloadSingle(ctx, url, settings, arch, interactive, tr)
+	tr.finish("", 0, err)
+	return err
+}
+
+func downloadSingle(ctx context.Context, url string, settings jobSettings, arch *archive.Archive,
+	interactive bool, tr *tracker) error {
+
+	adp, found := adapter.Find(url)
+	if !found {
+		return fmt.Errorf("no adapter for URL: %s", url)
+	}
+
+	tmpl, playlistTmpl, err := settings.templates(adp)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := archivedID(arch, adp, url); ok {
+		log.Info("Already downloaded, skipping", "id", id, "archive", arch.Path())
+		tr.id = id
+		tr.skip("archived")
+		return nil
+	}
+
+	tr.start()
+	info, err := adp.GetInfo(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to get media info: %w", err)
+	}
+	tr.info(info)
+
+	downloadOpts := settings.opts
+	if info.Type == adapter.MediaTypePlaylist {
+		if settings.output == stdoutPath {
+			return errors.New("writing to stdout (-o -) needs a single video, got a playlist")
+		}
+		tr.stage = "download"
+		return runPlaylistDownload(ctx, adp, info, downloadOpts, playlistTmpl, arch, settings.filter)
+	}
+	if !settings.filter.Match(info) {
+		log.Info("Skipping, does not match filters", "title", info.Title)
+		tr.skip("filtered")
+		return nil
+	}
+
+	if downloadOpts.FormatSelector == "" && (interactive || ui.IsInteractive()) && len(info.Formats) > 0 {
+		formatSelector := views.NewFormatSelector(
+			fmt.Sprintf("Select format for: %s", info.Title),
+			info.Formats,
+		).WithDuration(info.Duration)
+
+		model, err := ui.Run(ctx, formatSelector)
+		if err != nil {
+			return fmt.Errorf("format selection failed: %w", err)
+		}
 
-	progressView := views.NewDownloadProgress(outputPath)
+		result := model.(views.FormatSelectorModel)
+		if result.Cancelled() {
+			log.Warn("Download cancelled")
+			tr.finish("", 0, context.Canceled)
+			return nil
+		}
+		downloadOpts = selectedFormats(downloadOpts, result.Selected(), result.SelectedAudio())
+	}
 
-	type downloadResult struct {
-		reader io.ReadCloser
-		err    error
+	downloadOpts, sel, err := chooseFormats(info, downloadOpts)
+	if err != nil {
+		return err
 	}
-	resultCh := make(chan downloadResult, 1)
+	if sel != nil {
+		log.Info("Selected format", "format", sel.FormatIDs(), "quality", sel.Formats[0].Quality,
+			"ext", outputExtension(sel, downloadOpts), "reason", sel.Reason)
+	}
+	tr.format(sel, downloadOpts)
+
+	outputPath := resolveOutputPath(tmpl, outtmpl.InfoFields(info), sel, downloadOpts)
 
+	switch {
+	case settings.output == stdoutPath:
+		err = runStdoutDownload(ctx, adp, info, downloadOpts, tr)
+	case ui.IsInteractive():
+		err = runInteractiveDownload(ctx, adp, info, downloadOpts, outputPath, tr)
+	default:
+		err = runNonInteractiveDownload(ctx, adp, info, downloadOpts, outputPath, tr)
+	}
+	switch {
+	case errors.Is(err, outfile.ErrExists):
+		log.Info("File already exists, skipping", "file", outputPath)
+	case errors.Is(err, context.Canceled) && ctx.Err() == nil:
+		log.Warn("Download cancelled")
+		return nil
+	case err != nil:
+		return err
+	}
+	recordDownload(arch, adp, info)
+	return nil
+}
+
+func runBatchDownload(ctx context.Context, jobs []batchJob, settings jobSettings, arch *archive.Archive) error {
+	q := newDownloadQueue(arch, settings.filter.MaxDownloads())
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := q.addURL(ctx, job.URL, settings.withOverrides(job)); err != nil {
+			log.Warn("Skipping URL", "url", job.URL, "err", err)
+			q.failed++
+		}
+	}
+	return q.run(ctx)
+}
+
+func runInteractiveDownload(parent context.Context, adp adapter.Adapter,
+	info *adapter.MediaInfo, opts adapter.DownloadOptions, outputPath string, tr *tracker) error {
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	program := ui.Program(parent, views.NewDownloadProgress(outputPath).WithCancel(cancel))
+	tr.send = func(e events.Event) { program.Send(e) }
+
+	type outcome struct {
+		path    string
+		written int64
+		err     error
+	}
+	done := make(chan outcome, 1)
 	go func() {
-		reader, err := adp.Download(ctx, info, opts, func(p adapter.DownloadProgress) {
-			progressView.SetProgress(p.Downloaded, p.Total)
-		})
-		resultCh <- downloadResult{reader, err}
+		path, written, err := downloadVerified(ctx, adp, info, opts, outputPath, tr)
+		tr.finish(path, written, err)
+		done <- outcome{path, written, err}
 	}()
 
-	result := <-resultCh
-	if result.err != nil {
-		return fmt.Errorf("download failed: %w", result.err)
+	if _, err := program.Run(); err != nil {
+		cancel()
+		<-done
+		return fmt.Errorf("progress view failed: %w", err)
 	}
+	res := <-done
+	if res.err != nil {
+		return res.err
+	}
+
+	log.Info("Downloaded", "file", res.path, "size", tui.FormatBytes(res.written))
+	return nil
+}
+
+func runNonInteractiveDownload(ctx context.Context, adp adapter.Adapter,
+	info *adapter.MediaInfo, opts adapter.DownloadOptions, outputPath string, tr *tracker) error {
 
-	written, err := writeToFile(result.reader, outputPath)
+	out := plainOutput(os.Stdout)
+	tr.send = func(e events.Event) { printProgress(out, e) }
+	outputPath, written, err := downloadVerified(ctx, adp, info, opts, outputPath, tr)
+	_, _ = fmt.Fprintln(out)
+	tr.finish(outputPath, written, err)
 	if err != nil {
-		return fmt.Errorf("failed to write file %q: %w", outputPath, err)
+		return err
 	}
 
 	log.Info("Downloaded", "file", outputPath, "size", tui.FormatBytes(written))
 	return nil
 }
 
-func runNonInteractiveDownload(ctx context.Context, adp adapter.Adapter,
-	info *adapter.MediaInfo, opts adapter.DownloadOptions, outputPath string) error {
+func runStdoutDownload(ctx context.Context, adp adapter.Adapter, info *adapter.MediaInfo,
+	opts adapter.DownloadOptions, tr *tracker) error {
+
+	tr.send = func(e events.Event) { printProgress(os.Stderr, e) }
+	written, err := streamDownload(ctx, adp, info, opts, os.Stdout, tr)
+	_, _ = fmt.Fprintln(os.Stderr)
+	tr.finish(stdoutPath, written, err)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Downloaded", "file", "<stdout>", "size", tui.FormatBytes(written))
+	return nil
+}
 
-	reader, err := adp.Download(ctx, info, opts, func(p adapter.DownloadProgress) {
-		if p.Total > 0 {
-			percent := float64(p.Downloaded) / float64(p.Total) * 100
-			fmt.Printf("\rProgress: %.1f%% (%s / %s)",
-				percent, tui.FormatBytes(p.Downloaded), tui.FormatBytes(p.Total))
+func validateSelection(format, formatSort, quality string) error {
+	if format != "" {
+		if _, err := selector.Parse(format); err != nil {
+			return err
 		}
-	})
+	}
+	if _, err := selector.ParseSort(formatSort); err != nil {
+		return err
+	}
+	_, err := selector.ParseQuality(quality)
+	return err
+}
+
+func chooseFormats(info *adapter.MediaInfo, opts adapter.DownloadOptions) (adapter.DownloadOptions, *adapter.Selection, error) {
+	if len(info.Formats) == 0 {
+		return opts, nil, nil
+	}
+
+	sel, err := adapter.ChooseFormats(info.Formats, opts, formatRanker)
+	if err != nil {
+		return opts, nil, fmt.Errorf("select format: %w", err)
+	}
+
+	opts.FormatID = ""
+	opts.FormatSelector = sel.FormatIDs()
+	return opts, sel, nil
+}
+
+func selectedFormats(opts adapter.DownloadOptions, video, audio *adapter.Format) adapter.DownloadOptions {
+	switch {
+	case video != nil && audio != nil:
+		opts.FormatID, opts.FormatSelector = "", video.ID+"+"+audio.ID
+	case video != nil:
+		opts.FormatID = video.ID
+	}
+	return opts
+}
+
+func outputExtension(sel *adapter.Selection, opts adapter.DownloadOptions) string {
+	if sel != nil {
+		switch ext := sel.Extension(); {
+		case ext == "mp4" && !sel.Formats[0].IsVideo():
+			return "m4a"
+		case ext != "":
+			return ext
+		}
+	}
+	if opts.AudioOnly {
+		return "m4a"
+	}
+	if ext := cfg.OutputFormat; ext != "" {
+		return ext
+	}
+	return "mp4"
+}
+
+func outputTemplate(adp adapter.Adapter, flagValue string, playlist bool) (*outtmpl.Template, error) {
+	src := flagValue
+	if src == "" {
+		src = templateSource(adp, playlist)
+	}
+	return outtmpl.Parse(src)
+}
+
+func templateSource(adp adapter.Adapter, playlist bool) string {
+	if src := cfg.Template(adp.Name(), playlist); src != "" {
+		return src
+	}
+	if playlist {
+		return outtmpl.DefaultPlaylistTemplate
+	}
+	return outtmpl.DefaultTemplate
+}
+
+func resolveOutputPath(tmpl *outtmpl.Template, fields outtmpl.Fields, sel *adapter.Selection, opts adapter.DownloadOptions) string {
+	var formats []adapter.Format
+	if sel != nil {
+		formats = sel.Formats
+	}
+	path := tmpl.Path(fields.WithFormats(formats, outputExtension(sel, opts)))
+	if !filepath.IsAbs(path) && cfg.DownloadDir != "" {
+		path = filepath.Join(cfg.DownloadDir, path)
+	}
+	return path
+}
+
+func openArchive(path string, disabled bool) (*archive.Archive, error) {
+	if disabled || (path == "" && !cfg.Archive) {
+		return nil, nil
+	}
+	if path == "" {
+		path = cfg.DownloadArchive
+	}
+	return archive.Open(path)
+}
+
+func archivedID(arch *archive.Archive, adp adapter.Adapter, url string) (string, bool) {
+	if arch == nil {
+		return "", false
+	}
+	extractor, ok := adp.(adapter.IDExtractor)
+	if !ok {
+		return "", false
+	}
+	id, ok := extractor.ExtractID(url)
+	return id, ok && arch.Has(adp.Name(), id)
+}
+
+func recordDownload(arch *archive.Archive, adp adapter.Adapter, info *adapter.MediaInfo) {
+	if arch == nil || info.ID == "" {
+		return
+	}
+	if err := arch.Add(adp.Name(), info.ID); err != nil {
+		log.Warn("Failed to update download archive", "archive", arch.Path(), "err", err)
+	}
+}
+
+var mediaExtensions = map[string]bool{
+	"mp4": true, "m4a": true, "m4v": true, "mov": true, "3gp": true, "webm": true, "weba": true,
+	"mkv": true, "mka": true, "mp3": true, "aac": true, "ogg": true, "opus": true, "ts": true,
+}
+
+var errTransfer = errors.New("transfer interrupted")
+
+const (
+	stdoutPath       = "-"
+	progressInterval = 100 * time.Millisecond
+)
+
+func downloadVerified(ctx context.Context, adp adapter.Adapter, info *adapter.MediaInfo,
+	opts adapter.DownloadOptions, outputPath string, tr *tracker) (string, int64, error) {
+
+	policy := outfile.Policy(cfg.Overwrites)
+	final, err := policy.Claim(outputPath, verify.SidecarExt)
 	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
+		return outputPath, 0, err
+	}
+	part := outfile.PartPath(final)
+	meta, resumable := outfile.ReadPartMeta(part)
+	if st, err := os.Stat(part); resumable && err == nil && st.Size() > 0 && st.Size() < meta.Size {
+		opts.Offset = st.Size()
+	}
+
+	var ext string
+	attempts := max(cfg.Retries, 0) + 1
+	for attempt := 1; ; attempt++ {
+		result, err := adp.Download(ctx, info, opts, tr.progress)
+		if err == nil && result.Offset > 0 && partMeta(result) != meta {
+			_ = result.Reader.Close()
+			log.Info("Partial download does not match the selected format, restarting", "file", part)
+			opts.Offset = 0
+			result, err = adp.Download(ctx, info, opts, tr.progress)
+		}
+		if err != nil {
+			discardPart(part, tr)
+			return final, 0, fmt.Errorf("download failed: %w", err)
+		}
+		if attempt == 1 {
+			tr.merge(&result.Selection)
+			if result.Offset > 0 {
+				log.Info("Resuming partial download", "file", part, "resume_at", result.Offset)
+			}
+		}
+		if meta = partMeta(result); meta.Format != "" {
+			if err := outfile.WritePartMeta(part, meta); err != nil {
+				log.Debug("Failed to record partial download", "file", outfile.PartMetaPath(part), "err", err)
+			}
+		}
+
+		sniffed, size, err := saveDownload(ctx, result, part)
+		if sniffed != "" {
+			ext = sniffed
+		}
+		if err == nil && cfg.Verify {
+			tr.postProcess("verify", part)
+			_, err = verify.File(part, verify.Options{Size: result.Size})
+		}
+		if err == nil {
+			path, err := commitDownload(policy, part, sniffedPath(final, ext, &result.Selection), tr)
+			return path, size, err
+		}
+
+		retry := errors.Is(err, verify.ErrCorrupt) || errors.Is(err, errTransfer)
+		if ctx.Err() != nil || !retry || attempt >= attempts {
+			discardPart(part, tr)
+			if ctx.Err() != nil {
+				return final, 0, ctx.Err()
+			}
+			if retry {
+				return final, 0, fmt.Errorf("download failed after %d attempts: %w", attempt, err)
+			}
+			return final, 0, fmt.Errorf("failed to write file %q: %w", final, err)
+		}
+
+		opts.Offset = 0
+		if result.Resumable() {
+			if st, statErr := os.Stat(part); statErr == nil && st.Size() < result.Size {
+				opts.Offset = st.Size()
+			}
+		}
+		log.Warn("Download incomplete, retrying", "file", final, "attempt", attempt+1, "resume_at", opts.Offset, "err", err)
+		tr.retry(attempt+1, err)
+	}
+}
+
+func streamDownload(ctx context.Context, adp adapter.Adapter, info *adapter.MediaInfo,
+	opts adapter.DownloadOptions, w io.Writer, tr *tracker) (int64, error) {
+
+	var written int64
+	attempts := max(cfg.Retries, 0) + 1
+	for attempt := 1; ; attempt++ {
+		result, err := adp.Download(ctx, info, opts, tr.progress)
+		if err != nil {
+			return written, fmt.Errorf("download failed: %w", err)
+		}
+		if attempt == 1 {
+			tr.merge(&result.Selection)
+		}
+
+		src := &readTracker{r: result.Reader}
+		stop := closeOnCancel(ctx, result.Reader)
+		n, err := io.Copy(w, src)
+		stop()
+		_ = result.Reader.Close()
+		written += n
+		switch {
+		case src.err != nil:
+			err = fmt.Errorf("%w: %v", errTransfer, src.err)
+		case err != nil:
+			return written, fmt.Errorf("failed to write to stdout: %w", err)
+		default:
+			err = verify.Size(written, result.Size)
+		}
+		if err == nil {
+			return written, nil
+		}
+
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+		if attempt >= attempts || written > 0 && !result.Resumable() {
+			return written, fmt.Errorf("download failed after %d attempts: %w", attempt, err)
+		}
+		opts.Offset = written
+		log.Warn("Download incomplete, retrying", "file", "<stdout>", "attempt", attempt+1, "resume_at", opts.Offset, "err", err)
+		tr.retry(attempt+1, err)
 	}
+}
 
-	written, err := writeToFile(reader, outputPath)
-	fmt.Println()
+func commitDownload(policy outfile.Policy, part, path string, tr *tracker) (string, error) {
+	path, err := policy.Commit(part, path, verify.SidecarExt)
 	if err != nil {
-		return fmt.Errorf("failed to write file %q: %w", outputPath, err)
+		return path, err
 	}
+	tr.postProcess("commit", path)
 
-	log.Info("Downloaded", "file", outputPath, "size", tui.FormatBytes(written))
-	return nil
+	if !cfg.Checksum {
+		if err := os.Remove(verify.SidecarPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warn("Failed to remove stale checksum", "file", verify.SidecarPath(path), "err", err)
+		}
+		return path, nil
+	}
+
+	tr.postProcess("checksum", verify.SidecarPath(path))
+	if _, err := verify.WriteSidecar(path); err != nil {
+		log.Warn("Failed to write checksum", "file", verify.SidecarPath(path), "err", err)
+	}
+	return path, nil
+}
+
+func discardPart(part string, tr *tracker) {
+	if cfg.KeepPartial {
+		if st, err := os.Stat(part); err == nil && st.Size() > 0 {
+			log.Debug("Keeping partial download", "file", part)
+			tr.keepPartial(part)
+			outfile.Release(part)
+			return
+		}
+	}
+	outfile.Discard(part)
+}
+
+func partMeta(result *adapter.DownloadResult) outfile.PartMeta {
+	if !result.Resumable() {
+		return outfile.PartMeta{}
+	}
+	return outfile.PartMeta{Format: result.Formats[0].ID, Size: result.Size}
+}
+
+func closeOnCancel(ctx context.Context, c io.Closer) func() bool {
+	return context.AfterFunc(ctx, func() { _ = c.Close() })
 }
 
-func writeToFile(reader io.ReadCloser, outputPath string) (int64, error) {
-	defer func() { _ = reader.Close() }()
+func saveDownload(ctx context.Context, result *adapter.DownloadResult, part string) (string, int64, error) {
+	defer func() { _ = result.Reader.Close() }()
+	defer closeOnCancel(ctx, result.Reader)()
 
-	file, err := os.Create(outputPath)
+	if result.Offset > 0 {
+		written, err := writeToFile(result.Reader, part, result.Offset)
+		return "", result.Offset + written, err
+	}
+
+	src := &readTracker{r: result.Reader}
+	ext, reader, err := sniff.Peek(src)
+	if src.err != nil {
+		return "", 0, fmt.Errorf("%w: %v", errTransfer, src.err)
+	}
 	if err != nil {
-		return 0, err
+		return "", 0, err
 	}
-	defer func() { _ = file.Close() }()
+	written, err := writeToFile(reader, part, 0)
+	return ext, written, err
+}
+
+func sniffedPath(path, ext string, sel *adapter.Selection) string {
+	if ext == "" {
+		return path
+	}
+	if ext == "mp4" && len(sel.Formats) > 0 && !sel.HasVideo() {
+		ext = "m4a"
+	}
+	current := filepath.Ext(path)
+	if current == "."+ext || !mediaExtensions[strings.ToLower(strings.TrimPrefix(current, "."))] {
+		return path
+	}
+	log.Debug("Correcting file extension from content", "from", current, "to", "."+ext)
+	return strings.TrimSuffix(path, current) + "." + ext
+}
 
-	written, err := io.Copy(file, reader)
+func writeToFile(reader io.Reader, outputPath string, offset int64) (int64, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		if err := os.Truncate(outputPath, offset); err != nil {
+			return 0, err
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(outputPath, flags, 0o644)
 	if err != nil {
-		_ = os.Remove(outputPath)
 		return 0, err
 	}
-	return written, nil
+	defer func() { _ = file.Close() }()
+
+	src := &readTracker{r: reader}
+	written, err := io.Copy(file, src)
+	if src.err != nil {
+		return written, fmt.Errorf("%w: %v", errTransfer, src.err)
+	}
+	return written, err
 }
 
-func sanitizeFilename(name string) string {
-	replacer := strings.NewReplacer(
-		"/", "-",
-		"\\", "-",
-		":", "-",
-		"*", "",
-		"?", "",
-		"\"", "",
-		"<", "",
-		">", "",
-		"|", "",
-	)
-	name = replacer.Replace(name)
-	if len(name) > 200 {
-		name = name[:200]
+type readTracker struct {
+	r   io.Reader
+	err error
+}
+
+func (t *readTracker) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err != nil && err != io.EOF {
+		t.err = err
+	}
+	return n, err
+}
+
+func parseSection(section string) (time.Duration, time.Duration, error) {
+	if section == "" {
+		return 0, 0, nil
+	}
+
+	startStr, endStr, found := strings.Cut(strings.TrimPrefix(section, "*"), "-")
+	if !found || startStr == "" && endStr == "" {
+		return 0, 0, fmt.Errorf("invalid section %q: expected START-END", section)
 	}
-	return strings.TrimSpace(name)
+
+	var start, end time.Duration
+	var err error
+	if startStr != "" {
+		if start, err = parseTimestamp(startStr); err != nil {
+			return 0, 0, fmt.Errorf("invalid section start %q: %w", startStr, err)
+		}
+	}
+	if endStr != "" {
+		if end, err = parseTimestamp(endStr); err != nil {
+			return 0, 0, fmt.Errorf("invalid section end %q: %w", endStr, err)
+		}
+		if end <= start {
+			return 0, 0, fmt.Errorf("invalid section %q: end must be after start", section)
+		}
+	}
+	return start, end, nil
 }
 
-func runPlaylistDownload(ctx context.Context, adp adapter.Adapter,
-	playlist *adapter.MediaInfo, quality string, audioOnly bool) error {
+func parseTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("too many components")
+	}
+
+	var seconds float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("not a number: %q", part)
+		}
+		if i < len(parts)-1 && value != float64(int(value)) {
+			return 0, fmt.Errorf("only seconds may be fractional")
+		}
+		seconds = seconds*60 + value
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func runPlaylistDownload(ctx context.Context, adp adapter.Adapter, playlist *adapter.MediaInfo,
+	opts adapter.DownloadOptions, tmpl *outtmpl.Template, arch *archive.Archive, f *filter.Filter) error {
 	if len(playlist.Items) == 0 {
 		log.Warn("Playlist is empty")
 		return nil
 	}
 
-	var itemsToDownload []adapter.MediaInfo
+	var (
+		itemsToDownload []adapter.MediaInfo
+		filtered        int
+	)
 
 	if ui.IsInteractive() {
 		browser := views.NewPlaylistBrowser(
 			fmt.Sprintf("Select videos from: %s", playlist.Title),
 			playlist.Items,
-		)
+		).WithPreview(newPreview()).WithDetails(adp.GetInfo)
+		if f.Active() {
+			var ids []string
+			for _, i := range f.Select(playlist.Items) {
+				ids = append(ids, playlist.Items[i].ID)
+			}
+			browser = browser.WithSelected(ids)
+		}
 
-		model, err := ui.Run(browser)
+		model, err := ui.Run(ctx, browser)
 		if err != nil {
 			return err
 		}
@@ -222,62 +848,22 @@ func runPlaylistDownload(ctx context.Context, adp adapter.Adapter,
 			return nil
 		}
 
-		itemsToDownload = result.SelectedItems()
+		itemsToDownload = f.Order(result.SelectedItems())
 		if len(itemsToDownload) == 0 {
 			log.Warn("No videos selected")
 			return nil
 		}
 	} else {
-		itemsToDownload = playlist.Items
-	}
-
-	total := len(itemsToDownload)
-	for i, item := range itemsToDownload {
-		log.Info("Downloading", "video", fmt.Sprintf("%d/%d", i+1, total), "title", item.Title)
-
-		videoInfo, err := adp.GetInfo(ctx, item.URL)
-		if err != nil {
-			log.Warn("Skipping video, failed to get info", "title", item.Title, "err", err)
-			continue
-		}
-
-		downloadOpts := adapter.DownloadOptions{
-			Quality:   quality,
-			AudioOnly: audioOnly,
-		}
-
-		ext := "mp4"
-		if audioOnly {
-			ext = "m4a"
-		}
-		outputPath := sanitizeFilename(item.Title) + "." + ext
-
-		if err := downloadSingleVideo(ctx, adp, videoInfo, downloadOpts, outputPath); err != nil {
-			log.Warn("Skipping video, download failed", "title", item.Title, "err", err)
-			continue
-		}
-
-		log.Info("Downloaded", "file", outputPath)
-	}
-
-	log.Info("Playlist download complete", "downloaded", total)
-	return nil
-}
-
-func downloadSingleVideo(ctx context.Context, adp adapter.Adapter,
-	info *adapter.MediaInfo, opts adapter.DownloadOptions, outputPath string) error {
-	reader, err := adp.Download(ctx, info, opts, func(p adapter.DownloadProgress) {
-		if p.Total > 0 {
-			percent := float64(p.Downloaded) / float64(p.Total) * 100
-			fmt.Printf("\r  Progress: %.1f%% (%s / %s)",
-				percent, tui.FormatBytes(p.Downloaded), tui.FormatBytes(p.Total))
+		itemsToDownload = f.Apply(playlist.Items)
+		filtered = len(playlist.Items) - len(itemsToDownload)
+		if len(itemsToDownload) == 0 {
+			log.Warn("No playlist items match the filters", "items", len(playlist.Items))
+			return nil
 		}
-	})
-	if err != nil {
-		return err
 	}
 
-	_, err = writeToFile(reader, outputPath)
-	fmt.Println()
-	return err
+	q := newDownloadQueue(arch, f.MaxDownloads())
+	q.filtered = filtered
+	q.addPlaylist(adp, playlist, itemsToDownload, opts, tmpl, f)
+	return q.run(ctx)
 }
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/internal/events"
+	"github.com/aiomayo/aiodl/internal/outfile"
+	"github.com/aiomayo/aiodl/internal/tui"
+)
+
+type progressMode string
+
+const (
+	progressAuto  progressMode = "auto"
+	progressPlain progressMode = "plain"
+	progressJSON  progressMode = "json"
+)
+
+var (
+	eventSink    events.Sink
+	progressKind = progressAuto
+)
+
+func parseProgressMode(s string) (progressMode, error) {
+	switch m := progressMode(strings.ToLower(s)); m {
+	case progressAuto, progressPlain, progressJSON:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid --progress %q (want auto, plain or json)", s)
+}
+
+func openEventSinks(mode progressMode, fd int) (events.Sink, error) {
+	var sinks []events.Sink
+	if mode == progressJSON {
+		sinks = append(sinks, events.NewWriter(os.Stdout))
+	}
+	if fd > 0 {
+		f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+		if f == nil {
+			return nil, fmt.Errorf("invalid --events-fd %d", fd)
+		}
+		if _, err := f.Stat(); err != nil {
+			return nil, fmt.Errorf("invalid --events-fd %d: %w", fd, err)
+		}
+		sinks = append(sinks, events.NewWriter(f))
+	}
+	return events.Multi(sinks...), nil
+}
+
+func plainOutput(w io.Writer) io.Writer {
+	if progressKind == progressJSON {
+		return io.Discard
+	}
+	return w
+}
+
+type tracker struct {
+	mu       sync.Mutex
+	send     func(events.Event)
+	item     int
+	items    int
+	id       string
+	url      string
+	title    string
+	stage    string
+	partial  string
+	playlist bool
+	done     bool
+	meter    events.Meter
+	last     time.Time
+}
+
+func newTracker(url string, send func(events.Event)) *tracker {
+	return &tracker{url: url, send: send}
+}
+
+func (t *tracker) emit(e events.Event) {
+	t.mu.Lock()
+	e.Item, e.Items, e.ID, e.URL, e.Title = t.item, t.items, t.id, t.url, t.title
+	if e.Finished() {
+		e.Partial = t.partial
+	}
+	playlist := t.playlist
+	t.mu.Unlock()
+
+	if e.Finished() && !playlist {
+		session.record(e)
+	}
+	if eventSink != nil {
+		eventSink.Emit(e)
+	}
+	if t.send != nil {
+		t.send(e)
+	}
+}
+
+func (t *tracker) start() {
+	t.mu.Lock()
+	t.meter, t.last = events.Meter{}, time.Time{}
+	t.stage, t.partial, t.done = "info", "", false
+	t.mu.Unlock()
+	t.emit(events.New(events.TypeStart))
+}
+
+func (t *tracker) info(info *adapter.MediaInfo) {
+	t.mu.Lock()
+	t.id, t.title = info.ID, info.Title
+	t.playlist = info.Type == adapter.MediaTypePlaylist
+	if info.URL != "" {
+		t.url = info.URL
+	}
+	t.stage = "format selection"
+	t.mu.Unlock()
+
+	e := events.New(events.TypeInfo)
+	e.Info = &events.Info{
+		Type:     string(info.Type),
+		Platform: info.Platform,
+		Uploader: info.Uploader,
+		Duration: info.Duration,
+		IsLive:   info.IsLive,
+		Formats:  len(info.Formats),
+		Entries:  len(info.Items),
+	}
+	if !info.UploadDate.IsZero() {
+		e.Info.UploadDate = info.UploadDate.Format("20060102")
+	}
+	t.emit(e)
+}
+
+func (t *tracker) format(sel *adapter.Selection, opts adapter.DownloadOptions) {
+	t.mu.Lock()
+	t.stage = "download"
+	t.mu.Unlock()
+	if sel == nil {
+		return
+	}
+	e := events.New(events.TypeFormat)
+	e.Format = &events.Format{
+		IDs:     sel.FormatIDs(),
+		Quality: sel.Formats[0].Quality,
+		Ext:     outputExtension(sel, opts),
+		Reason:  sel.Reason,
+	}
+	for _, f := range sel.Formats {
+		e.Format.Formats = append(e.Format.Formats, f.ID)
+	}
+	t.emit(e)
+}
+
+func (t *tracker) progress(p adapter.DownloadProgress) {
+	t.mu.Lock()
+	now := time.Now()
+	if now.Sub(t.last) < progressInterval && p.Downloaded != p.Total {
+		t.mu.Unlock()
+		return
+	}
+	t.last = now
+	e := events.New(events.TypeProgress)
+	e.Progress = t.meter.Progress(p.Downloaded, p.Total)
+	t.mu.Unlock()
+	t.emit(e)
+}
+
+func (t *tracker) merge(sel *adapter.Selection) {
+	if len(sel.Formats) < 2 {
+		return
+	}
+	e := events.New(events.TypeMerge)
+	e.Format = &events.Format{IDs: sel.FormatIDs()}
+	for _, f := range sel.Formats {
+		e.Format.Formats = append(e.Format.Formats, f.ID)
+	}
+	t.emit(e)
+}
+
+func (t *tracker) postProcess(step, path string) {
+	e := events.New(events.TypePostProcess)
+	e.Step, e.Path = step, path
+	t.emit(e)
+}
+
+func (t *tracker) retry(attempt int, err error) {
+	e := events.New(events.TypeRetry)
+	e.Attempt, e.Error = attempt, err.Error()
+	t.emit(e)
+}
+
+func (t *tracker) keepPartial(path string) {
+	t.mu.Lock()
+	t.partial = path
+	t.mu.Unlock()
+}
+
+func (t *tracker) skip(reason string) {
+	e := events.New(events.TypeSkipped)
+	e.Reason = reason
+	t.end(e)
+}
+
+func (t *tracker) finish(path string, size int64, err error) {
+	var e events.Event
+	switch {
+	case err == nil:
+		e = events.New(events.TypeDone)
+		e.Path, e.Size = path, size
+	case errors.Is(err, outfile.ErrExists):
+		e = events.New(events.TypeSkipped)
+		e.Path, e.Reason = path, "exists"
+	case errors.Is(err, errFiltered):
+		e = events.New(events.TypeSkipped)
+		e.Reason = "filtered"
+	case errors.Is(err, errItemSkipped):
+		e = events.New(events.TypeSkipped)
+		e.Reason = "user"
+	case errors.Is(err, context.Canceled), errors.Is(err, errItemCancelled):
+		e = events.New(events.TypeCancelled)
+	default:
+		e = events.New(events.TypeError)
+		e.Error = err.Error()
+		t.mu.Lock()
+		e.Stage = t.stage
+		t.mu.Unlock()
+	}
+	t.end(e)
+}
+
+func (t *tracker) end(e events.Event) {
+	t.mu.Lock()
+	if t.done {
+		t.mu.Unlock()
+		return
+	}
+	t.done = true
+	t.mu.Unlock()
+	t.emit(e)
+}
+
+func printProgress(w io.Writer, e events.Event) {
+	p := e.Progress
+	if e.Type != events.TypeProgress || p.Total <= 0 {
+		return
+	}
+	line := fmt.Sprintf("Progress: %.1f%% (%s / %s", p.Percent, tui.FormatBytes(p.Downloaded), tui.FormatBytes(p.Total))
+	if p.Speed > 0 {
+		line += ", " + tui.FormatBytes(p.Speed) + "/s"
+	}
+	if p.ETA > 0 {
+		line += ", ETA " + tui.FormatDuration(p.ETA)
+	}
+	_, _ = fmt.Fprintf(w, "\r%-64s", line+")")
+}
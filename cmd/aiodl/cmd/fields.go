@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/internal/filter"
+)
+
+func newFieldsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "fields",
+		Short: "List metadata fields available to --match-filter",
+		Long: `List metadata fields available to --match-filter and which adapters provide them.
+
+"entry" fields are known from a playlist listing and filter items before they
+are fetched. "info" fields need the per-item metadata request; an expression
+using them is re-checked once that request has been made.
+
+Operators: = != < <= > >= for numbers, dates and durations; = != ^= $= *= for
+strings; ~= and !~ for regular expressions. Combine with &&, || and !, group
+with parentheses. A missing field fails its comparison unless the operator is
+followed by '?' (e.g., "view_count >? 1000").`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names := adapter.List()
+			sets := make([]adapter.FieldSet, len(names))
+			for i, name := range names {
+				if adp, ok := adapter.Get(name); ok {
+					if lister, ok := adp.(adapter.FieldLister); ok {
+						sets[i] = lister.MetadataFields()
+					}
+				}
+			}
+
+			fields := make([]string, 0, len(filter.Schema))
+			for name := range filter.Schema {
+				fields = append(fields, name)
+			}
+			slices.Sort(fields)
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintf(w, "FIELD\tTYPE\t%s\n", strings.ToUpper(strings.Join(names, "\t")))
+			for _, field := range fields {
+				row := []string{field, filter.Schema[field].String()}
+				for _, set := range sets {
+					switch {
+					case slices.Contains(set.Entry, field):
+						row = append(row, "entry")
+					case slices.Contains(set.Info, field):
+						row = append(row, "info")
+					default:
+						row = append(row, "-")
+					}
+				}
+				_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -1,19 +1,23 @@
 package cmd
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 
+	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 
 	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/internal/outtmpl"
 	"github.com/aiomayo/aiodl/internal/tui/views"
 )
 
 func newInfoCmd() *cobra.Command {
-	var jsonOutput bool
+	var (
+		jsonOutput bool
+		printTmpl  string
+	)
 
 	cmd := &cobra.Command{
 		Use:   "info [URL]",
@@ -21,7 +25,15 @@ func newInfoCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			url := args[0]
-			ctx := context.Background()
+			ctx := cmd.Context()
+
+			var tmpl *outtmpl.Template
+			if printTmpl != "" {
+				var err error
+				if tmpl, err = parsePrintTemplate(printTmpl); err != nil {
+					return err
+				}
+			}
 
 			adp, found := adapter.Find(url)
 			if !found {
@@ -33,13 +45,24 @@ func newInfoCmd() *cobra.Command {
 				return err
 			}
 
+			if tmpl != nil {
+				printInfo(os.Stdout, tmpl, info)
+				return nil
+			}
 			if jsonOutput {
 				enc := json.NewEncoder(os.Stdout)
 				enc.SetIndent("", "  ")
 				return enc.Encode(info)
 			}
 
-			_, _ = fmt.Print(views.RenderInfo(info))
+			if preview := newPreview(); ui.IsInteractive() && preview.Enabled() {
+				if err := preview.Fetch(ctx, info); err != nil {
+					log.Debug("Thumbnail unavailable", "err", err)
+				}
+				_, _ = fmt.Print(preview.RenderInfo(info))
+			} else {
+				_, _ = fmt.Print(views.RenderInfo(info))
+			}
 
 			if info.Type == adapter.MediaTypePlaylist && len(info.Items) > 0 {
 				_, _ = fmt.Printf("\nPlaylist videos:\n")
@@ -51,6 +74,8 @@ func newInfoCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
+	cmd.Flags().StringVar(&printTmpl, "print", "", "print a template for the video or each playlist item (e.g., \"{id}\\t{title}\\t{duration}\")")
+	cmd.MarkFlagsMutuallyExclusive("json", "print")
 
 	return cmd
 }
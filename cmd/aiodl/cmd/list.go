@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/charmbracelet/log"
@@ -20,7 +19,7 @@ func newListCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			url := args[0]
-			ctx := context.Background()
+			ctx := cmd.Context()
 
 			adp, found := adapter.Find(url)
 			if !found {
@@ -42,7 +41,7 @@ func newListCmd() *cobra.Command {
 					fmt.Sprintf("Formats for: %s", info.Title),
 					info.Formats,
 				)
-				_, err := ui.Run(selector)
+				_, err := ui.Run(ctx, selector)
 				return err
 			}
 
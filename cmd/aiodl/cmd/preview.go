@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aiomayo/aiodl/internal/config"
+	"github.com/aiomayo/aiodl/internal/tui/graphics"
+	"github.com/aiomayo/aiodl/internal/tui/views"
+)
+
+func newPreview() *views.Preview {
+	return views.NewPreview(graphics.NewLoader(thumbnailClient(cfg.Network)), graphics.NewRenderer(graphics.Detect()))
+}
+
+func thumbnailClient(n config.NetworkConfig) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{Timeout: n.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	t.TLSHandshakeTimeout = n.TLSHandshakeTimeout
+	t.ResponseHeaderTimeout = n.ResponseHeaderTimeout
+	return &http.Client{
+		Transport: t,
+		Timeout:   n.ConnectTimeout + n.TLSHandshakeTimeout + n.ResponseHeaderTimeout + n.ReadTimeout,
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/internal/outtmpl"
+)
+
+var printEscapes = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n")
+
+func parsePrintTemplate(src string) (*outtmpl.Template, error) {
+	return outtmpl.Parse(printEscapes.Replace(src))
+}
+
+type printer struct {
+	w    io.Writer
+	tmpl *outtmpl.Template
+}
+
+func runPrint(ctx context.Context, jobs []batchJob, settings jobSettings, p *printer) error {
+	failed := 0
+	for _, job := range jobs {
+		if err := p.printURL(ctx, job.URL, settings.withOverrides(job)); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Warn("Skipping URL", "url", job.URL, "err", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d URLs failed", failed, len(jobs))
+	}
+	return nil
+}
+
+func (p *printer) printURL(ctx context.Context, url string, settings jobSettings) error {
+	adp, found := adapter.Find(url)
+	if !found {
+		return fmt.Errorf("no adapter for URL: %s", url)
+	}
+	tmpl, playlistTmpl, err := settings.templates(adp)
+	if err != nil {
+		return err
+	}
+
+	info, err := adp.GetInfo(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to get media info: %w", err)
+	}
+	if info.Type != adapter.MediaTypePlaylist {
+		if !settings.filter.Match(info) {
+			log.Info("Skipping, does not match filters", "title", info.Title)
+			return nil
+		}
+		return p.printItem(info, nil, 0, settings, tmpl)
+	}
+
+	fetch := p.needsInfo(adp)
+	for _, item := range settings.filter.Apply(info.Items) {
+		entry := &item
+		if fetch {
+			if entry, err = adp.GetInfo(ctx, item.URL); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Warn("Skipping video", "title", item.Title, "err", err)
+				continue
+			}
+			if entry.Index == 0 {
+				entry.Index = item.Index
+			}
+			if !settings.filter.Match(entry) {
+				continue
+			}
+		}
+		if err := p.printItem(entry, info, item.Index, settings, playlistTmpl); err != nil {
+			log.Warn("Skipping video", "title", item.Title, "err", err)
+		}
+	}
+	return nil
+}
+
+func (p *printer) needsInfo(adp adapter.Adapter) bool {
+	lister, ok := adp.(adapter.FieldLister)
+	if p.tmpl == nil || !ok {
+		return true
+	}
+	entry := lister.MetadataFields().Entry
+	for _, name := range p.tmpl.Fields() {
+		if !slices.Contains(entry, name) && !strings.HasPrefix(name, "playlist") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) printItem(info, playlist *adapter.MediaInfo, index int, settings jobSettings, tmpl *outtmpl.Template) error {
+	opts, sel, err := chooseFormats(info, settings.opts)
+	if err != nil {
+		return err
+	}
+
+	if p.tmpl == nil {
+		if sel == nil {
+			return errors.New("no formats available")
+		}
+		for _, f := range sel.Formats {
+			if f.URL == "" {
+				return fmt.Errorf("format %s has no direct URL", f.ID)
+			}
+			_, _ = fmt.Fprintln(p.w, f.URL)
+		}
+		return nil
+	}
+
+	fields := outtmpl.InfoFields(info).WithPlaylist(playlist, index)
+	fields["filename"] = resolveOutputPath(tmpl, fields, sel, opts)
+	_, _ = fmt.Fprintln(p.w, p.tmpl.Render(fields))
+	return nil
+}
+
+func printInfo(w io.Writer, tmpl *outtmpl.Template, info *adapter.MediaInfo) {
+	if info.Type != adapter.MediaTypePlaylist {
+		_, _ = fmt.Fprintln(w, tmpl.Render(outtmpl.InfoFields(info)))
+		return
+	}
+	for _, item := range info.Items {
+		_, _ = fmt.Fprintln(w, tmpl.Render(outtmpl.InfoFields(&item).WithPlaylist(info, item.Index)))
+	}
+}
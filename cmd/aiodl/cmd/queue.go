@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/internal/archive"
+	"github.com/aiomayo/aiodl/internal/events"
+	"github.com/aiomayo/aiodl/internal/filter"
+	"github.com/aiomayo/aiodl/internal/outfile"
+	"github.com/aiomayo/aiodl/internal/outtmpl"
+	"github.com/aiomayo/aiodl/internal/scheduler"
+	"github.com/aiomayo/aiodl/internal/tui"
+)
+
+type downloadQueue struct {
+	arch     *archive.Archive
+	limit    int
+	limited  bool
+	items    []queueItem
+	skipped  int
+	filtered int
+	failed   int
+}
+
+type queueItem struct {
+	adp      adapter.Adapter
+	info     adapter.MediaInfo
+	resolved bool
+	playlist *adapter.MediaInfo
+	opts     adapter.DownloadOptions
+	tmpl     *outtmpl.Template
+	filter   *filter.Filter
+}
+
+var errFiltered = errors.New("does not match filters")
+
+func (it queueItem) title() string {
+	if it.info.Title != "" {
+		return it.info.Title
+	}
+	return it.info.URL
+}
+
+type itemOutcome struct {
+	stage     string
+	info      *adapter.MediaInfo
+	selection *adapter.Selection
+	path      string
+	size      int64
+	tracker   *tracker
+}
+
+func newDownloadQueue(arch *archive.Archive, limit int) *downloadQueue {
+	return &downloadQueue{arch: arch, limit: limit}
+}
+
+func (q *downloadQueue) addURL(ctx context.Context, url string, job jobSettings) error {
+	adp, found := adapter.Find(url)
+	if !found {
+		return fmt.Errorf("no adapter for URL: %s", url)
+	}
+	tmpl, playlistTmpl, err := job.templates(adp)
+	if err != nil {
+		return err
+	}
+
+	if extractor, ok := adp.(adapter.IDExtractor); ok {
+		if id, ok := extractor.ExtractID(url); ok {
+			q.add(queueItem{adp: adp, info: adapter.MediaInfo{ID: id, URL: url}, opts: job.opts, tmpl: tmpl, filter: job.filter})
+			return nil
+		}
+	}
+
+	info, err := adp.GetInfo(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to get media info: %w", err)
+	}
+	if info.Type == adapter.MediaTypePlaylist {
+		items := job.filter.Apply(info.Items)
+		q.filtered += len(info.Items) - len(items)
+		q.addPlaylist(adp, info, items, job.opts, playlistTmpl, job.filter)
+		return nil
+	}
+	q.add(queueItem{adp: adp, info: *info, resolved: true, opts: job.opts, tmpl: tmpl, filter: job.filter})
+	return nil
+}
+
+func (q *downloadQueue) addPlaylist(adp adapter.Adapter, playlist *adapter.MediaInfo, items []adapter.MediaInfo,
+	opts adapter.DownloadOptions, tmpl *outtmpl.Template, f *filter.Filter) {
+	for _, item := range items {
+		q.add(queueItem{adp: adp, info: item, playlist: playlist, opts: opts, tmpl: tmpl, filter: f})
+	}
+}
+
+func (q *downloadQueue) add(item queueItem) {
+	if item.resolved && !item.filter.Match(&item.info) {
+		log.Info("Skipping, does not match filters", "title", item.title())
+		q.filtered++
+		return
+	}
+	if q.arch != nil && item.info.ID != "" && q.arch.Has(item.adp.Name(), item.info.ID) {
+		log.Info("Already downloaded, skipping", "title", item.title(), "archive", q.arch.Path())
+		q.skipped++
+		return
+	}
+	if q.limit > 0 && len(q.items) >= q.limit {
+		if !q.limited {
+			log.Info("Reached --max-downloads, ignoring remaining items", "max", q.limit)
+			q.limited = true
+		}
+		q.skipped++
+		return
+	}
+	q.items = append(q.items, item)
+}
+
+func (q *downloadQueue) run(ctx context.Context) error {
+	pool := scheduler.New(cfg.Parallel)
+	total := len(q.items)
+	if total > 0 && ui.IsInteractive() {
+		return q.runDashboard(ctx, pool)
+	}
+	if total > 0 {
+		log.Info("Starting downloads", "items", total, "parallel", pool.Workers())
+	}
+
+	progress := tui.NewProgressLine(plainOutput(os.Stdout), total)
+	outcomes := make([]itemOutcome, total)
+	tasks := make([]scheduler.Task, total)
+	for i, item := range q.items {
+		outcomes[i].tracker = q.tracker(i, func(e events.Event) {
+			if e.Type == events.TypeProgress {
+				progress.Update(i, e.Progress.Downloaded, e.Progress.Total)
+			}
+		})
+		tasks[i] = scheduler.Task{
+			Name: item.title(),
+			Run: func(ctx context.Context) error {
+				err := downloadQueueItem(ctx, item, &outcomes[i])
+				outcomes[i].tracker.finish(outcomes[i].path, outcomes[i].size, err)
+				return err
+			},
+		}
+	}
+
+	downloaded, filtered, existing := 0, 0, 0
+	err := pool.Run(ctx, tasks, func(r scheduler.Result) {
+		progress.Finish(r.Index)
+		out := outcomes[r.Index]
+		name := r.Name
+		if out.info != nil && out.info.Title != "" {
+			name = out.info.Title
+		}
+		progress.Println(func() {
+			video := fmt.Sprintf("%d/%d", r.Index+1, total)
+			switch {
+			case ctx.Err() != nil && errors.Is(r.Err, ctx.Err()):
+			case errors.Is(r.Err, errFiltered):
+				filtered++
+				log.Info("Skipping, does not match filters", "video", video, "title", name)
+			case errors.Is(r.Err, outfile.ErrExists):
+				existing++
+				log.Info("File already exists, skipping", "video", video, "file", out.path)
+			case r.Err != nil && out.stage != "":
+				log.Warn("Skipping video, "+out.stage+" failed", "video", video, "title", name, "err", r.Err)
+			case r.Err != nil:
+				log.Warn("Skipping video", "video", video, "title", name, "err", r.Err)
+			default:
+				downloaded++
+				if out.selection != nil {
+					log.Info("Selected format", "video", video, "format", out.selection.FormatIDs(), "reason", out.selection.Reason)
+				}
+				log.Info("Downloaded", "video", video, "file", out.path)
+			}
+		})
+		if r.Err == nil || errors.Is(r.Err, outfile.ErrExists) {
+			recordDownload(q.arch, q.items[r.Index].adp, out.info)
+		}
+	})
+	progress.Close()
+
+	if ctx.Err() != nil {
+		log.Warn("Download cancelled", "downloaded", downloaded, "remaining", total-downloaded)
+		return ctx.Err()
+	}
+	return q.summarize(total, downloaded, filtered, existing, 0, err)
+}
+
+func (q *downloadQueue) summarize(total, downloaded, filtered, existing, skipped int, err error) error {
+	failed := total - downloaded - filtered - existing - skipped + q.failed
+	log.Info("Download complete", "downloaded", downloaded, "failed", failed, "skipped", q.skipped+existing+skipped,
+		"filtered", q.filtered+filtered)
+	switch {
+	case failed == 0:
+		return nil
+	case err != nil:
+		return fmt.Errorf("%d of %d downloads failed: %w", failed, total+q.failed, err)
+	default:
+		return fmt.Errorf("%d of %d downloads failed", failed, total+q.failed)
+	}
+}
+
+func (q *downloadQueue) tracker(i int, send func(events.Event)) *tracker {
+	item := q.items[i]
+	t := newTracker(item.info.URL, send)
+	t.item, t.items = i+1, len(q.items)
+	t.id, t.title = item.info.ID, item.info.Title
+	t.emit(events.New(events.TypeQueued))
+	return t
+}
+
+func downloadQueueItem(ctx context.Context, item queueItem, out *itemOutcome) error {
+	out.stage = "info"
+	out.tracker.start()
+	info := &item.info
+	if !item.resolved {
+		var err error
+		if info, err = item.adp.GetInfo(ctx, item.info.URL); err != nil {
+			return err
+		}
+	}
+	out.info = info
+	out.tracker.info(info)
+	if info.Index == 0 {
+		info.Index = item.info.Index
+	}
+	if !item.filter.Match(info) {
+		return errFiltered
+	}
+
+	out.stage = "format selection"
+	opts := item.opts
+	opts.FormatID = ""
+	opts, sel, err := chooseFormats(info, opts)
+	if err != nil {
+		return err
+	}
+	out.selection = sel
+	out.tracker.format(sel, opts)
+
+	index := item.info.Index
+	fields := outtmpl.InfoFields(info).WithPlaylist(item.playlist, index)
+	out.path = resolveOutputPath(item.tmpl, fields, sel, opts)
+
+	out.stage = "download"
+	if out.path, out.size, err = downloadVerified(ctx, item.adp, info, opts, out.path, out.tracker); err != nil {
+		return err
+	}
+	out.stage = ""
+	return nil
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/charmbracelet/log"
@@ -10,11 +11,17 @@ import (
 	"github.com/aiomayo/aiodl/internal/adapter"
 	"github.com/aiomayo/aiodl/internal/config"
 	"github.com/aiomayo/aiodl/internal/tui"
+	"github.com/aiomayo/aiodl/internal/tui/theme"
+	"github.com/aiomayo/aiodl/internal/tui/views"
+	"github.com/aiomayo/aiodl/selector"
+	"github.com/aiomayo/aiodl/youtube"
 )
 
 var (
-	v  = viper.New()
-	ui *tui.UI
+	v            = viper.New()
+	ui           *tui.UI
+	cfg          *config.Config
+	formatRanker selector.Ranker
 )
 
 var rootCmd = &cobra.Command{
@@ -22,9 +29,16 @@ var rootCmd = &cobra.Command{
 	Short:         "A unified media downloader",
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	Args:          cobra.NoArgs,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		return initConfig()
 	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !ui.IsInteractive() {
+			return cmd.Help()
+		}
+		return runApp(cmd.Context())
+	},
 }
 
 var (
@@ -49,7 +63,11 @@ func init() {
 	rootCmd.AddCommand(newDownloadCmd())
 	rootCmd.AddCommand(newInfoCmd())
 	rootCmd.AddCommand(newListCmd())
+	rootCmd.AddCommand(newURLCmd())
 	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newArchiveCmd())
+	rootCmd.AddCommand(newFieldsCmd())
+	rootCmd.AddCommand(newVerifyCmd())
 }
 
 func initConfig() error {
@@ -63,18 +81,58 @@ func initConfig() error {
 		v.SetConfigFile(cfgFile)
 	}
 
-	if _, err := config.Load(v); err != nil {
+	var err error
+	if cfg, err = config.Load(v); err != nil {
 		return err
 	}
 
-	_ = adapter.Register(adapter.NewYouTubeAdapter())
+	t, err := theme.New(cfg.Theme.Name, cfg.Theme.Colors)
+	if err != nil {
+		return fmt.Errorf("invalid theme: %w", err)
+	}
+	views.SetTheme(t)
+	if err := views.SetKeyBindings(cfg.Keybindings); err != nil {
+		return fmt.Errorf("invalid keybindings: %w", err)
+	}
+
+	if err := cfg.Network.Validate(); err != nil {
+		return fmt.Errorf("invalid network config: %w", err)
+	}
+	ytOpts := networkOptions(cfg.Network)
+	if pref := cfg.FormatPreference; !pref.IsDefault() {
+		policy := pref.Policy()
+		formatRanker = policy
+		ytOpts = append(ytOpts, youtube.WithFormatRanker(youtube.RankPolicy(policy)))
+	}
+
+	_ = adapter.Register(adapter.NewYouTubeAdapter(ytOpts...))
 	return nil
 }
 
+func networkOptions(n config.NetworkConfig) []youtube.Option {
+	return []youtube.Option{
+		youtube.WithConnectTimeout(n.ConnectTimeout),
+		youtube.WithTLSHandshakeTimeout(n.TLSHandshakeTimeout),
+		youtube.WithResponseHeaderTimeout(n.ResponseHeaderTimeout),
+		youtube.WithReadTimeout(n.ReadTimeout),
+		youtube.WithStallRetries(n.StallRetries),
+		youtube.WithMaxIdleConnsPerHost(n.MaxIdleConnsPerHost),
+		youtube.WithHTTP2(n.HTTP2),
+	}
+}
+
 func Execute() {
 	rootCmd.Version = version
 
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := notifyInterrupt()
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	if code := interruptCode.Load(); code != 0 {
+		session.report()
+		os.Exit(int(code))
+	}
+	if err != nil {
 		log.Error("Command failed", "err", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/aiomayo/aiodl/internal/events"
+)
+
+var (
+	session       sessionSummary
+	interruptCode atomic.Int32
+)
+
+func notifyInterrupt() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-sigs:
+		case <-ctx.Done():
+			return
+		}
+		interruptCode.Store(int32(exitCode(sig)))
+		log.Warn("Interrupted, stopping downloads (press Ctrl-C again to force quit)")
+		cancel()
+
+		<-sigs
+		log.Error("Force quit")
+		os.Exit(exitCode(sig))
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		cancel()
+	}
+}
+
+func exitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 130
+}
+
+type sessionSummary struct {
+	mu        sync.Mutex
+	completed []string
+	partial   []string
+	failed    int
+	cancelled int
+	skipped   int
+}
+
+func (s *sessionSummary) record(e events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch e.Type {
+	case events.TypeDone:
+		s.completed = append(s.completed, e.Path)
+	case events.TypeSkipped:
+		s.skipped++
+	case events.TypeCancelled:
+		s.cancelled++
+	case events.TypeError:
+		s.failed++
+	}
+	if e.Partial != "" {
+		s.partial = append(s.partial, e.Partial)
+	}
+}
+
+func (s *sessionSummary) report() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log.Warn("Interrupted", "completed", len(s.completed), "cancelled", s.cancelled, "failed", s.failed, "skipped", s.skipped)
+	for _, path := range s.completed {
+		log.Info("Completed", "file", path)
+	}
+	for _, path := range s.partial {
+		log.Info("Kept partial download", "file", path)
+	}
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+)
+
+func newURLCmd() *cobra.Command {
+	var (
+		format     string
+		formatSort string
+		quality    string
+		audioOnly  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "url URL...",
+		Short: "Print direct media URLs for the selected formats",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("quality") {
+				quality = cfg.Quality
+			}
+			if err := validateSelection(format, formatSort, quality); err != nil {
+				return err
+			}
+
+			var jobs []batchJob
+			for _, url := range args {
+				jobs = append(jobs, batchJob{URL: url})
+			}
+			settings := jobSettings{
+				opts: adapter.DownloadOptions{
+					Quality:        quality,
+					FormatSelector: format,
+					FormatSort:     formatSort,
+					AudioOnly:      audioOnly,
+					Container:      cfg.Container(),
+				},
+			}
+			return runPrint(cmd.Context(), jobs, settings, &printer{w: os.Stdout})
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "", "format ID (itag) or selector (e.g., bv[height<=1080]+ba/b)")
+	cmd.Flags().StringVarP(&formatSort, "format-sort", "S", "", "sort order for best/worst (e.g., res,fps,codec:av1,size)")
+	cmd.Flags().StringVarP(&quality, "quality", "q", "", "quality: best, worst, audio, 1080p (nearest lower) or <=1080p (default from config)")
+	cmd.Flags().BoolVarP(&audioOnly, "audio-only", "x", false, "audio only")
+
+	return cmd
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+
+	"github.com/aiomayo/aiodl/internal/tui"
+	"github.com/aiomayo/aiodl/internal/verify"
+)
+
+func newVerifyCmd() *cobra.Command {
+	var writeChecksums bool
+
+	cmd := &cobra.Command{
+		Use:   "verify [PATH...]",
+		Short: "Check downloaded files for truncation and corruption",
+		Long: `Check downloaded media files for truncation and corruption.
+
+Directories are searched recursively for media files. Each file is checked for
+a well-formed MP4 or WebM structure and, when a .sha256 sidecar exists, against
+its checksum.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var files []string
+			for _, arg := range args {
+				err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+					if err != nil {
+						return err
+					}
+					ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+					if !d.IsDir() && (path == arg || mediaExtensions[ext]) {
+						files = append(files, path)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if len(files) == 0 {
+				log.Warn("No media files found")
+				return nil
+			}
+
+			failed := 0
+			for _, path := range files {
+				res, err := verify.File(path, verify.Options{Checksum: true})
+				if err != nil {
+					failed++
+					log.Error("Verification failed", "file", path, "err", err)
+					continue
+				}
+				checksum := res.Checksum
+				if checksum == "" && writeChecksums {
+					if checksum, err = verify.WriteSidecar(path); err != nil {
+						log.Warn("Failed to write checksum", "file", verify.SidecarPath(path), "err", err)
+					}
+				}
+				kv := []any{"file", path, "container", res.Container, "size", tui.FormatBytes(res.Size)}
+				if checksum != "" {
+					kv = append(kv, "sha256", checksum[:12])
+				}
+				log.Info("OK", kv...)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d files failed verification", failed, len(files))
+			}
+			log.Info("Verification complete", "files", len(files))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&writeChecksums, "write-checksums", false, "write .sha256 sidecars for files that have none")
+
+	return cmd
+}
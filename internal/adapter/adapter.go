@@ -3,6 +3,7 @@ package adapter
 import (
 	"context"
 	"io"
+	"time"
 )
 
 type MediaType string
@@ -14,34 +15,70 @@ const (
 )
 
 type MediaInfo struct {
-	ID       string
-	Title    string
-	Duration int
-	Type     MediaType
-	URL      string
-	Platform string
-	Formats  []Format
-	Items    []MediaInfo
+	ID          string
+	Title       string
+	Description string
+	Duration    int
+	Type        MediaType
+	URL         string
+	Platform    string
+	Uploader    string
+	UploaderID  string
+	UploadDate  time.Time
+	ViewCount   int64
+	IsLive      bool
+	WasLive     bool
+	Index       int
+	Thumbnails  []Thumbnail
+	Formats     []Format
+	Items       []MediaInfo
+}
+
+type Thumbnail struct {
+	URL    string
+	Width  int
+	Height int
 }
 
 type Format struct {
-	ID        string
-	Extension string
-	Quality   string
-	FileSize  int64
-	Bitrate   int
-	Width     int
-	Height    int
+	ID         string
+	Extension  string
+	Quality    string
+	FileSize   int64
+	Bitrate    int
+	Width      int
+	Height     int
+	FPS        int
+	VideoCodec string
+	AudioCodec string
+	Language   string
+	HDR        bool
+	Seekable   bool
+	URL        string
 }
 
 func (f *Format) IsVideo() bool {
 	return f.Width > 0 || f.Height > 0
 }
 
+func (f *Format) HasAudio() bool {
+	return f.AudioCodec != "" || !f.IsVideo()
+}
+
 type DownloadOptions struct {
-	FormatID  string
-	Quality   string
-	AudioOnly bool
+	FormatID       string
+	FormatSelector string
+	FormatSort     string
+	Quality        string
+	AudioOnly      bool
+	Container      string
+	Start          time.Duration
+	End            time.Duration
+	Offset         int64
+}
+
+func (o DownloadOptions) HasSection() bool {
+	return o.Start > 0 || o.End > 0
 }
 
 type DownloadProgress struct {
@@ -55,5 +92,29 @@ type Adapter interface {
 	Name() string
 	Matches(url string) bool
 	GetInfo(ctx context.Context, url string) (*MediaInfo, error)
-	Download(ctx context.Context, info *MediaInfo, opts DownloadOptions, progress ProgressFunc) (io.ReadCloser, error)
+	Download(ctx context.Context, info *MediaInfo, opts DownloadOptions, progress ProgressFunc) (*DownloadResult, error)
+}
+
+type DownloadResult struct {
+	Reader io.ReadCloser
+	Size   int64
+	Offset int64
+	Selection
+}
+
+func (r *DownloadResult) Resumable() bool {
+	return r.Size > 0 && len(r.Formats) == 1
+}
+
+type IDExtractor interface {
+	ExtractID(url string) (string, bool)
+}
+
+type FieldSet struct {
+	Entry []string
+	Info  []string
+}
+
+type FieldLister interface {
+	MetadataFields() FieldSet
 }
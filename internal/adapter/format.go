@@ -0,0 +1,159 @@
+package adapter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aiomayo/aiodl/selector"
+)
+
+type Selection struct {
+	Formats []Format
+	Reason  string
+}
+
+func (s *Selection) FormatIDs() string {
+	ids := make([]string, len(s.Formats))
+	for i, f := range s.Formats {
+		ids[i] = f.ID
+	}
+	return strings.Join(ids, "+")
+}
+
+func (s *Selection) Extension() string {
+	for _, f := range s.Formats {
+		if f.IsVideo() {
+			return f.Extension
+		}
+	}
+	if len(s.Formats) == 0 {
+		return ""
+	}
+	return s.Formats[0].Extension
+}
+
+func (s *Selection) HasVideo() bool {
+	for _, f := range s.Formats {
+		if f.IsVideo() {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Format) Candidate() selector.Candidate {
+	fields := selector.Fields{"ext": f.Extension}
+	set := func(name string, value any, ok bool) {
+		if ok {
+			fields[name] = value
+		}
+	}
+	set("vcodec", f.VideoCodec, f.VideoCodec != "")
+	set("acodec", f.AudioCodec, f.AudioCodec != "")
+	set("height", f.Height, f.Height > 0)
+	set("width", f.Width, f.Width > 0)
+	set("fps", f.FPS, f.FPS > 0)
+	set("tbr", float64(f.Bitrate)/1000, f.Bitrate > 0)
+	set("filesize", f.FileSize, f.FileSize > 0)
+	set("quality", f.Quality, f.Quality != "")
+	set("format_note", f.Quality, f.Quality != "")
+	set("lang", f.Language, f.Language != "")
+	if f.IsVideo() {
+		fields["dynamic_range"] = "SDR"
+		if f.HDR {
+			fields["dynamic_range"] = "HDR"
+		}
+	}
+
+	return selector.Candidate{
+		ID:        f.ID,
+		Video:     f.IsVideo(),
+		Audio:     f.HasAudio(),
+		Container: f.Extension,
+		Fields:    fields,
+	}
+}
+
+func ChooseFormats(formats []Format, opts DownloadOptions, r selector.Ranker) (*Selection, error) {
+	if opts.HasSection() {
+		var seekable []Format
+		for _, f := range formats {
+			if f.Seekable {
+				seekable = append(seekable, f)
+			}
+		}
+		formats = seekable
+	}
+
+	candidates := make([]selector.Candidate, len(formats))
+	for i := range formats {
+		candidates[i] = formats[i].Candidate()
+	}
+	compare := func(a, b int) int {
+		if r != nil {
+			if c := r.Compare(candidates[a], candidates[b]); c != 0 {
+				return c
+			}
+		}
+		return selector.Compare(candidates[a], candidates[b], selector.DefaultSort)
+	}
+	pick := func(picked []int, reason string) *Selection {
+		sel := &Selection{Reason: reason}
+		for _, idx := range picked {
+			sel.Formats = append(sel.Formats, formats[idx])
+		}
+		return sel
+	}
+
+	switch {
+	case opts.FormatID != "":
+		for i, f := range formats {
+			if f.ID == opts.FormatID {
+				return pick([]int{i}, fmt.Sprintf("format %s requested", f.ID)), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: format %s", selector.ErrNoMatch, opts.FormatID)
+	case opts.FormatSelector != "":
+		e, err := selector.Parse(opts.FormatSelector)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := selector.ParseSort(opts.FormatSort)
+		if err != nil {
+			return nil, err
+		}
+		picked, err := e.SelectFunc(candidates, func(a, b int) int {
+			if c := selector.Compare(candidates[a], candidates[b], keys); c != 0 {
+				return c
+			}
+			return compare(a, b)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return pick(picked, fmt.Sprintf("matched %q", opts.FormatSelector)), nil
+	}
+
+	if f, ok := r.(selector.Filter); ok {
+		idx := f.Filter(candidates)
+		kept, keptCandidates := make([]Format, len(idx)), make([]selector.Candidate, len(idx))
+		for i, j := range idx {
+			kept[i], keptCandidates[i] = formats[j], candidates[j]
+		}
+		formats, candidates = kept, keptCandidates
+	}
+
+	quality := opts.Quality
+	if opts.AudioOnly {
+		quality = "audio"
+	}
+	q, err := selector.ParseQuality(quality)
+	if err != nil {
+		return nil, err
+	}
+	picked, reason, err := q.Select(candidates, opts.Container, compare)
+	if err != nil {
+		return nil, err
+	}
+	return pick(picked, reason), nil
+}
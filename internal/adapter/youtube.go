@@ -2,7 +2,6 @@ package adapter
 
 import (
 	"context"
-	"io"
 	"strconv"
 
 	"github.com/aiomayo/aiodl/youtube"
@@ -22,6 +21,28 @@ func (a *YouTubeAdapter) Matches(url string) bool {
 	return youtube.IsYouTubeURL(url)
 }
 
+func (a *YouTubeAdapter) ExtractID(url string) (string, bool) {
+	if _, err := youtube.ExtractPlaylistID(url); err == nil {
+		return "", false
+	}
+	id, err := youtube.ExtractVideoID(url)
+	return id, err == nil
+}
+
+func (a *YouTubeAdapter) MetadataFields() FieldSet {
+	return FieldSet{
+		Entry: []string{
+			"id", "title", "type", "url", "webpage_url", "platform", "extractor",
+			"uploader", "channel", "duration", "playlist_index",
+		},
+		Info: []string{
+			"id", "title", "description", "type", "url", "webpage_url", "platform", "extractor",
+			"uploader", "channel", "uploader_id", "upload_date", "duration", "view_count",
+			"is_live", "was_live",
+		},
+	}
+}
+
 func (a *YouTubeAdapter) GetInfo(ctx context.Context, url string) (*MediaInfo, error) {
 	if _, err := youtube.ExtractPlaylistID(url); err == nil {
 		return a.getPlaylistInfo(ctx, url)
@@ -36,29 +57,51 @@ func (a *YouTubeAdapter) getVideoInfo(ctx context.Context, url string) (*MediaIn
 	}
 
 	info := &MediaInfo{
-		ID:       video.ID,
-		Title:    video.Title,
-		Duration: int(video.Duration.Seconds()),
-		Type:     MediaTypeVideo,
-		URL:      url,
-		Platform: "youtube",
+		ID:          video.ID,
+		Title:       video.Title,
+		Description: video.Description,
+		Duration:    int(video.Duration.Seconds()),
+		Type:        MediaTypeVideo,
+		URL:         url,
+		Platform:    "youtube",
+		Uploader:    video.Author,
+		UploaderID:  video.AuthorID,
+		UploadDate:  video.PublishDate,
+		ViewCount:   video.ViewCount,
+		IsLive:      video.IsLive,
+		WasLive:     video.WasLive,
+	}
+
+	for _, t := range video.Thumbnails {
+		info.Thumbnails = append(info.Thumbnails, Thumbnail{URL: t.URL, Width: t.Width, Height: t.Height})
 	}
 
 	for _, f := range video.Formats() {
-		info.Formats = append(info.Formats, Format{
-			ID:        strconv.Itoa(f.ItagNo),
-			Extension: f.Extension(),
-			Quality:   f.QualityLabel,
-			FileSize:  f.ContentLength,
-			Bitrate:   f.Bitrate,
-			Width:     f.Width,
-			Height:    f.Height,
-		})
+		info.Formats = append(info.Formats, convertFormat(f))
 	}
 
 	return info, nil
 }
 
+func convertFormat(f youtube.Format) Format {
+	return Format{
+		ID:         strconv.Itoa(f.ItagNo),
+		Extension:  f.Extension(),
+		Quality:    f.QualityLabel,
+		FileSize:   f.ContentLength,
+		Bitrate:    f.Bitrate,
+		Width:      f.Width,
+		Height:     f.Height,
+		FPS:        f.FPS,
+		VideoCodec: f.VideoCodec(),
+		AudioCodec: f.AudioCodec(),
+		Language:   f.Language,
+		HDR:        f.HDR,
+		Seekable:   f.InitRange != nil && f.IndexRange != nil,
+		URL:        f.URL,
+	}
+}
+
 func (a *YouTubeAdapter) getPlaylistInfo(ctx context.Context, url string) (*MediaInfo, error) {
 	playlist, err := a.client.GetPlaylist(ctx, url)
 	if err != nil {
@@ -66,14 +109,20 @@ func (a *YouTubeAdapter) getPlaylistInfo(ctx context.Context, url string) (*Medi
 	}
 
 	info := &MediaInfo{
-		ID:       playlist.ID,
-		Title:    playlist.Title,
-		Type:     MediaTypePlaylist,
-		URL:      url,
-		Platform: "youtube",
+		ID:          playlist.ID,
+		Title:       playlist.Title,
+		Description: playlist.Description,
+		Type:        MediaTypePlaylist,
+		URL:         url,
+		Platform:    "youtube",
+		Uploader:    playlist.Author,
 	}
 
-	for _, entry := range playlist.Videos {
+	for i, entry := range playlist.Videos {
+		index := entry.Index
+		if index == 0 {
+			index = i + 1
+		}
 		info.Items = append(info.Items, MediaInfo{
 			ID:       entry.ID,
 			Title:    entry.Title,
@@ -81,13 +130,18 @@ func (a *YouTubeAdapter) getPlaylistInfo(ctx context.Context, url string) (*Medi
 			Type:     MediaTypeVideo,
 			URL:      "https://www.youtube.com/watch?v=" + entry.ID,
 			Platform: "youtube",
+			Uploader: entry.Author,
+			Index:    index,
+			Thumbnails: []Thumbnail{
+				{URL: "https://i.ytimg.com/vi/" + entry.ID + "/mqdefault.jpg", Width: 320, Height: 180},
+			},
 		})
 	}
 
 	return info, nil
 }
 
-func (a *YouTubeAdapter) Download(ctx context.Context, info *MediaInfo, opts DownloadOptions, progress ProgressFunc) (io.ReadCloser, error) {
+func (a *YouTubeAdapter) Download(ctx context.Context, info *MediaInfo, opts DownloadOptions, progress ProgressFunc) (*DownloadResult, error) {
 	video, err := a.client.GetVideo(ctx, info.URL)
 	if err != nil {
 		return nil, err
@@ -97,6 +151,12 @@ func (a *YouTubeAdapter) Download(ctx context.Context, info *MediaInfo, opts Dow
 		Itag:      parseItag(opts.FormatID),
 		Quality:   opts.Quality,
 		AudioOnly: opts.AudioOnly,
+		Container: opts.Container,
+		Format:    opts.FormatSelector,
+		Sort:      opts.FormatSort,
+		Start:     opts.Start,
+		End:       opts.End,
+		Offset:    opts.Offset,
 	}
 
 	var ytProgress youtube.ProgressFunc
@@ -106,7 +166,27 @@ func (a *YouTubeAdapter) Download(ctx context.Context, info *MediaInfo, opts Dow
 		}
 	}
 
-	return a.client.Download(ctx, video, ytOpts, ytProgress)
+	selection, err := a.client.SelectFormats(video, ytOpts)
+	if err != nil {
+		return nil, err
+	}
+	if len(selection.Formats) != 1 || opts.HasSection() || ytOpts.Offset >= selection.Formats[0].ContentLength {
+		ytOpts.Offset = 0
+	}
+	reader, err := a.client.DownloadSelection(ctx, selection, ytOpts, ytProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DownloadResult{Reader: reader, Selection: Selection{Reason: selection.Reason}}
+	for _, f := range selection.Formats {
+		result.Formats = append(result.Formats, convertFormat(f))
+	}
+	if len(selection.Formats) == 1 && !opts.HasSection() {
+		result.Size = selection.Formats[0].ContentLength
+		result.Offset = ytOpts.Offset
+	}
+	return result, nil
 }
 
 func parseItag(formatID string) int {
@@ -117,4 +197,8 @@ func parseItag(formatID string) int {
 	return itag
 }
 
-var _ Adapter = (*YouTubeAdapter)(nil)
+var (
+	_ Adapter     = (*YouTubeAdapter)(nil)
+	_ IDExtractor = (*YouTubeAdapter)(nil)
+	_ FieldLister = (*YouTubeAdapter)(nil)
+)
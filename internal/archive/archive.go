@@ -0,0 +1,191 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aiomayo/aiodl/internal/paths"
+)
+
+const FileName = "archive.txt"
+
+type Archive struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]struct{}
+	offset  int64
+}
+
+func DefaultPath() (string, error) {
+	return paths.DataFile(FileName)
+}
+
+func Open(path string) (*Archive, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return nil, fmt.Errorf("archive path: %w", err)
+		}
+	}
+
+	a := &Archive{path: path, entries: make(map[string]struct{})}
+	if err := a.refresh(); err != nil {
+		return nil, fmt.Errorf("reading archive %q: %w", path, err)
+	}
+	return a, nil
+}
+
+func Key(adapter, id string) string {
+	return strings.ToLower(adapter) + " " + id
+}
+
+func (a *Archive) Path() string {
+	return a.path
+}
+
+func (a *Archive) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.entries)
+}
+
+func (a *Archive) Has(adapter, id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_ = a.refresh()
+	_, ok := a.entries[Key(adapter, id)]
+	return ok
+}
+
+func (a *Archive) Add(adapter, id string) error {
+	_, err := a.append([]string{Key(adapter, id)})
+	return err
+}
+
+func (a *Archive) Import(r io.Reader) (int, error) {
+	var keys []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if key, ok := parseLine(scanner.Text()); ok {
+			keys = append(keys, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return a.append(keys)
+}
+
+func (a *Archive) append(keys []string) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(a.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := lockFile(f, true); err != nil {
+		return 0, fmt.Errorf("locking archive: %w", err)
+	}
+	defer func() { _ = unlockFile(f) }()
+
+	if err := a.readFrom(f); err != nil {
+		return 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() > a.offset {
+		if _, err := f.Write([]byte{'\n'}); err != nil {
+			return 0, err
+		}
+		if err := a.readFrom(f); err != nil {
+			return 0, err
+		}
+	}
+
+	var buf bytes.Buffer
+	added := 0
+	for _, key := range keys {
+		if _, ok := a.entries[key]; ok {
+			continue
+		}
+		a.entries[key] = struct{}{}
+		buf.WriteString(key)
+		buf.WriteByte('\n')
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+
+	n, err := f.Write(buf.Bytes())
+	a.offset += int64(n)
+	return added, err
+}
+
+func (a *Archive) refresh() error {
+	f, err := os.Open(a.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if info, err := f.Stat(); err == nil && info.Size() == a.offset {
+		return nil
+	}
+
+	if err := lockFile(f, false); err != nil {
+		return err
+	}
+	defer func() { _ = unlockFile(f) }()
+	return a.readFrom(f)
+}
+
+func (a *Archive) readFrom(f *os.File) error {
+	if _, err := f.Seek(a.offset, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	end := bytes.LastIndexByte(data, '\n') + 1
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		if key, ok := parseLine(line); ok {
+			a.entries[key] = struct{}{}
+		}
+	}
+	a.offset += int64(end)
+	return nil
+}
+
+func parseLine(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", false
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return "", false
+	}
+	return Key(fields[0], fields[1]), true
+}
@@ -3,10 +3,14 @@ package config
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 
+	"github.com/aiomayo/aiodl/internal/outtmpl"
 	"github.com/aiomayo/aiodl/internal/paths"
+	"github.com/aiomayo/aiodl/selector"
 )
 
 const (
@@ -14,11 +18,121 @@ const (
 )
 
 type Config struct {
-	DownloadDir  string `mapstructure:"download_dir"`
-	OutputFormat string `mapstructure:"output_format"`
-	Quality      string `mapstructure:"quality"`
-	Verbose      bool   `mapstructure:"verbose"`
-	Parallel     int    `mapstructure:"parallel"`
+	DownloadDir      string                         `mapstructure:"download_dir"`
+	OutputFormat     string                         `mapstructure:"output_format"`
+	Quality          string                         `mapstructure:"quality"`
+	Verbose          bool                           `mapstructure:"verbose"`
+	Parallel         int                            `mapstructure:"parallel"`
+	Archive          bool                           `mapstructure:"archive"`
+	DownloadArchive  string                         `mapstructure:"download_archive"`
+	OutputTemplate   string                         `mapstructure:"output_template"`
+	PlaylistTemplate string                         `mapstructure:"playlist_template"`
+	MatchFilter      string                         `mapstructure:"match_filter"`
+	Verify           bool                           `mapstructure:"verify"`
+	Checksum         bool                           `mapstructure:"checksum"`
+	Retries          int                            `mapstructure:"retries"`
+	Overwrites       string                         `mapstructure:"overwrites"`
+	KeepPartial      bool                           `mapstructure:"keep_partial"`
+	FormatPreference FormatPreference               `mapstructure:"format_preference"`
+	Adapters         map[string]AdapterConfig       `mapstructure:"adapters"`
+	Network          NetworkConfig                  `mapstructure:"network"`
+	Theme            ThemeConfig                    `mapstructure:"theme"`
+	Keybindings      map[string]map[string][]string `mapstructure:"keybindings"`
+}
+
+type NetworkConfig struct {
+	ConnectTimeout        time.Duration `mapstructure:"connect_timeout"`
+	TLSHandshakeTimeout   time.Duration `mapstructure:"tls_handshake_timeout"`
+	ResponseHeaderTimeout time.Duration `mapstructure:"response_header_timeout"`
+	ReadTimeout           time.Duration `mapstructure:"read_timeout"`
+	StallRetries          int           `mapstructure:"stall_retries"`
+	MaxIdleConnsPerHost   int           `mapstructure:"max_idle_conns_per_host"`
+	HTTP2                 bool          `mapstructure:"http2"`
+}
+
+func (n NetworkConfig) Validate() error {
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"connect_timeout", n.ConnectTimeout},
+		{"tls_handshake_timeout", n.TLSHandshakeTimeout},
+		{"response_header_timeout", n.ResponseHeaderTimeout},
+		{"read_timeout", n.ReadTimeout},
+	} {
+		if d.value < 0 {
+			return fmt.Errorf("network.%s must not be negative, got %s", d.name, d.value)
+		}
+	}
+	if n.StallRetries < 0 {
+		return fmt.Errorf("network.stall_retries must not be negative, got %d", n.StallRetries)
+	}
+	if n.MaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("network.max_idle_conns_per_host must not be negative, got %d", n.MaxIdleConnsPerHost)
+	}
+	return nil
+}
+
+type ThemeConfig struct {
+	Name   string            `mapstructure:"name"`
+	Colors map[string]string `mapstructure:"colors"`
+}
+
+type AdapterConfig struct {
+	OutputTemplate   string `mapstructure:"output_template"`
+	PlaylistTemplate string `mapstructure:"playlist_template"`
+}
+
+type FormatPreference struct {
+	VideoCodecs   []string `mapstructure:"video_codecs"`
+	AudioCodecs   []string `mapstructure:"audio_codecs"`
+	Containers    []string `mapstructure:"containers"`
+	MaxHeight     int      `mapstructure:"max_height"`
+	MaxFPS        int      `mapstructure:"max_fps"`
+	AllowHDR      bool     `mapstructure:"allow_hdr"`
+	PreferSmaller bool     `mapstructure:"prefer_smaller"`
+}
+
+func (p FormatPreference) IsDefault() bool {
+	return len(p.VideoCodecs) == 0 && len(p.AudioCodecs) == 0 && len(p.Containers) == 0 &&
+		p.MaxHeight == 0 && p.MaxFPS == 0 && p.AllowHDR && !p.PreferSmaller
+}
+
+func (p FormatPreference) Policy() selector.Policy {
+	return selector.Policy{
+		VideoCodecs:   p.VideoCodecs,
+		AudioCodecs:   p.AudioCodecs,
+		Containers:    p.Containers,
+		MaxHeight:     p.MaxHeight,
+		MaxFPS:        p.MaxFPS,
+		NoHDR:         !p.AllowHDR,
+		PreferSmaller: p.PreferSmaller,
+	}
+}
+
+func (c *Config) Container() string {
+	switch ext := strings.ToLower(strings.TrimPrefix(c.OutputFormat, ".")); ext {
+	case "m4a", "m4v", "mov":
+		return "mp4"
+	case "weba", "mka", "mkv":
+		return "webm"
+	default:
+		return ext
+	}
+}
+
+func (c *Config) Template(adapter string, playlist bool) string {
+	ac := c.Adapters[adapter]
+	if playlist {
+		if ac.PlaylistTemplate != "" {
+			return ac.PlaylistTemplate
+		}
+		return c.PlaylistTemplate
+	}
+	if ac.OutputTemplate != "" {
+		return ac.OutputTemplate
+	}
+	return c.OutputTemplate
 }
 
 func SetDefaults(v *viper.Viper) {
@@ -27,6 +141,32 @@ func SetDefaults(v *viper.Viper) {
 	v.SetDefault("quality", "best")
 	v.SetDefault("verbose", false)
 	v.SetDefault("parallel", 3)
+	v.SetDefault("archive", true)
+	v.SetDefault("download_archive", "")
+	v.SetDefault("output_template", outtmpl.DefaultTemplate)
+	v.SetDefault("playlist_template", outtmpl.DefaultPlaylistTemplate)
+	v.SetDefault("match_filter", "")
+	v.SetDefault("verify", true)
+	v.SetDefault("checksum", false)
+	v.SetDefault("retries", 3)
+	v.SetDefault("overwrites", "skip")
+	v.SetDefault("keep_partial", false)
+	v.SetDefault("format_preference.video_codecs", []string{})
+	v.SetDefault("format_preference.audio_codecs", []string{})
+	v.SetDefault("format_preference.containers", []string{})
+	v.SetDefault("format_preference.max_height", 0)
+	v.SetDefault("format_preference.max_fps", 0)
+	v.SetDefault("format_preference.allow_hdr", true)
+	v.SetDefault("format_preference.prefer_smaller", false)
+	v.SetDefault("network.connect_timeout", "15s")
+	v.SetDefault("network.tls_handshake_timeout", "10s")
+	v.SetDefault("network.response_header_timeout", "30s")
+	v.SetDefault("network.read_timeout", "30s")
+	v.SetDefault("network.stall_retries", 3)
+	v.SetDefault("network.max_idle_conns_per_host", 8)
+	v.SetDefault("network.http2", true)
+	v.SetDefault("theme.name", "default")
+	v.SetDefault("theme.colors", map[string]string{})
 }
 
 func Load(v *viper.Viper) (*Config, error) {
@@ -37,6 +177,7 @@ func Load(v *viper.Viper) (*Config, error) {
 	v.AddConfigPath(paths.ConfigDir())
 	v.AddConfigPath(".")
 	v.SetEnvPrefix("AIODL")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
 	if err := v.ReadInConfig(); err != nil {
@@ -0,0 +1,83 @@
+package events
+
+import "time"
+
+const SchemaVersion = 1
+
+type Type string
+
+const (
+	TypeQueued      Type = "queued"
+	TypeStart       Type = "start"
+	TypeInfo        Type = "info"
+	TypeFormat      Type = "format"
+	TypeProgress    Type = "progress"
+	TypeMerge       Type = "merge"
+	TypePostProcess Type = "postprocess"
+	TypeRetry       Type = "retry"
+	TypeDone        Type = "done"
+	TypeSkipped     Type = "skipped"
+	TypeCancelled   Type = "cancelled"
+	TypeError       Type = "error"
+)
+
+type Event struct {
+	Version  int       `json:"v"`
+	Type     Type      `json:"type"`
+	Time     time.Time `json:"time"`
+	Item     int       `json:"item,omitempty"`
+	Items    int       `json:"items,omitempty"`
+	ID       string    `json:"id,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Title    string    `json:"title,omitempty"`
+	Info     *Info     `json:"info,omitempty"`
+	Format   *Format   `json:"format,omitempty"`
+	Progress *Progress `json:"progress,omitempty"`
+	Step     string    `json:"step,omitempty"`
+	Attempt  int       `json:"attempt,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Partial  string    `json:"partial,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Stage    string    `json:"stage,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type Info struct {
+	Type       string `json:"type,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	Uploader   string `json:"uploader,omitempty"`
+	UploadDate string `json:"upload_date,omitempty"`
+	Duration   int    `json:"duration,omitempty"`
+	IsLive     bool   `json:"is_live,omitempty"`
+	Formats    int    `json:"formats,omitempty"`
+	Entries    int    `json:"entries,omitempty"`
+}
+
+type Format struct {
+	IDs     string   `json:"ids"`
+	Formats []string `json:"formats,omitempty"`
+	Quality string   `json:"quality,omitempty"`
+	Ext     string   `json:"ext,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+}
+
+type Progress struct {
+	Downloaded int64   `json:"downloaded"`
+	Total      int64   `json:"total,omitempty"`
+	Percent    float64 `json:"percent,omitempty"`
+	Speed      int64   `json:"speed"`
+	ETA        int     `json:"eta,omitempty"`
+}
+
+func New(t Type) Event {
+	return Event{Version: SchemaVersion, Type: t, Time: time.Now()}
+}
+
+func (e Event) Finished() bool {
+	switch e.Type {
+	case TypeDone, TypeSkipped, TypeCancelled, TypeError:
+		return true
+	}
+	return false
+}
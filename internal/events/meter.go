@@ -0,0 +1,34 @@
+package events
+
+import "time"
+
+const meterWindow = 500 * time.Millisecond
+
+type Meter struct {
+	last      time.Time
+	lastBytes int64
+	speed     float64
+}
+
+func (m *Meter) Progress(downloaded, total int64) *Progress {
+	now := time.Now()
+	switch {
+	case m.last.IsZero() || downloaded < m.lastBytes:
+		m.last, m.lastBytes, m.speed = now, downloaded, 0
+	case now.Sub(m.last) >= meterWindow, m.speed == 0 && now.Sub(m.last) >= meterWindow/5:
+		rate := float64(downloaded-m.lastBytes) / now.Sub(m.last).Seconds()
+		if m.speed > 0 {
+			rate = (m.speed + rate) / 2
+		}
+		m.last, m.lastBytes, m.speed = now, downloaded, rate
+	}
+
+	p := &Progress{Downloaded: downloaded, Total: total, Speed: int64(m.speed)}
+	if total > 0 {
+		p.Percent = min(float64(downloaded)/float64(total)*100, 100)
+		if m.speed > 0 && downloaded < total {
+			p.ETA = int(float64(total-downloaded)/m.speed + 0.5)
+		}
+	}
+	return p
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+type Sink interface {
+	Emit(Event)
+}
+
+type SinkFunc func(Event)
+
+func (f SinkFunc) Emit(e Event) {
+	f(e)
+}
+
+type Writer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{enc: json.NewEncoder(w)}
+}
+
+func (w *Writer) Emit(e Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = w.enc.Encode(e)
+	}
+}
+
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+type multiSink []Sink
+
+func (m multiSink) Emit(e Event) {
+	for _, s := range m {
+		s.Emit(e)
+	}
+}
+
+func Multi(sinks ...Sink) Sink {
+	var out multiSink
+	for _, s := range sinks {
+		if s != nil {
+			out = append(out, s)
+		}
+	}
+	switch len(out) {
+	case 0:
+		return nil
+	case 1:
+		return out[0]
+	}
+	return out
+}
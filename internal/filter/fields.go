@@ -0,0 +1,66 @@
+package filter
+
+import (
+	"time"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/match"
+)
+
+var Schema = match.Schema{
+	"id":             match.String,
+	"title":          match.String,
+	"description":    match.String,
+	"type":           match.String,
+	"url":            match.String,
+	"webpage_url":    match.String,
+	"platform":       match.String,
+	"extractor":      match.String,
+	"uploader":       match.String,
+	"channel":        match.String,
+	"uploader_id":    match.String,
+	"upload_date":    match.Date,
+	"duration":       match.Duration,
+	"view_count":     match.Number,
+	"is_live":        match.Bool,
+	"was_live":       match.Bool,
+	"playlist_index": match.Number,
+}
+
+func Fields(info *adapter.MediaInfo) match.Fields {
+	f := match.Fields{}
+	set := func(name, value string) {
+		if value != "" {
+			f[name] = value
+		}
+	}
+	set("id", info.ID)
+	set("title", info.Title)
+	set("description", info.Description)
+	set("type", string(info.Type))
+	set("url", info.URL)
+	set("webpage_url", info.URL)
+	set("platform", info.Platform)
+	set("extractor", info.Platform)
+	set("uploader", info.Uploader)
+	set("channel", info.Uploader)
+	set("uploader_id", info.UploaderID)
+
+	if !info.UploadDate.IsZero() {
+		f["upload_date"] = info.UploadDate
+	}
+	if info.Duration > 0 {
+		f["duration"] = time.Duration(info.Duration) * time.Second
+	}
+	if info.ViewCount > 0 {
+		f["view_count"] = info.ViewCount
+	}
+	if info.Index > 0 {
+		f["playlist_index"] = info.Index
+	}
+	if len(info.Formats) > 0 {
+		f["is_live"] = info.IsLive
+		f["was_live"] = info.WasLive
+	}
+	return f
+}
@@ -0,0 +1,283 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/match"
+)
+
+type Options struct {
+	Items        string
+	Reverse      bool
+	DateAfter    string
+	DateBefore   string
+	MinDuration  string
+	MaxDuration  string
+	MatchTitle   string
+	RejectTitle  string
+	MatchFilter  string
+	MaxDownloads int
+}
+
+type Filter struct {
+	items        []span
+	reverse      bool
+	dateAfter    time.Time
+	dateBefore   time.Time
+	minDuration  time.Duration
+	maxDuration  time.Duration
+	matchTitle   *regexp.Regexp
+	rejectTitle  *regexp.Regexp
+	expr         *match.Expr
+	maxDownloads int
+}
+
+func New(opts Options) (*Filter, error) {
+	f := &Filter{reverse: opts.Reverse, maxDownloads: opts.MaxDownloads}
+
+	var err error
+	if f.items, err = parseItems(opts.Items); err != nil {
+		return nil, err
+	}
+	if f.dateAfter, err = parseDate(opts.DateAfter); err != nil {
+		return nil, fmt.Errorf("date-after: %w", err)
+	}
+	if f.dateBefore, err = parseDate(opts.DateBefore); err != nil {
+		return nil, fmt.Errorf("date-before: %w", err)
+	}
+	if f.minDuration, err = parseDuration(opts.MinDuration); err != nil {
+		return nil, fmt.Errorf("min-duration: %w", err)
+	}
+	if f.maxDuration, err = parseDuration(opts.MaxDuration); err != nil {
+		return nil, fmt.Errorf("max-duration: %w", err)
+	}
+	if opts.MatchTitle != "" {
+		if f.matchTitle, err = regexp.Compile("(?i)" + opts.MatchTitle); err != nil {
+			return nil, fmt.Errorf("match-title: %w", err)
+		}
+	}
+	if opts.RejectTitle != "" {
+		if f.rejectTitle, err = regexp.Compile("(?i)" + opts.RejectTitle); err != nil {
+			return nil, fmt.Errorf("reject-title: %w", err)
+		}
+	}
+	if strings.TrimSpace(opts.MatchFilter) != "" {
+		if f.expr, err = match.Parse(opts.MatchFilter); err != nil {
+			return nil, err
+		}
+		if err := f.expr.Check(Schema); err != nil {
+			return nil, err
+		}
+	}
+	if f.maxDownloads < 0 {
+		return nil, fmt.Errorf("max-downloads must not be negative")
+	}
+	return f, nil
+}
+
+func (f *Filter) Active() bool {
+	return f != nil && (len(f.items) > 0 || !f.dateAfter.IsZero() || !f.dateBefore.IsZero() ||
+		f.minDuration > 0 || f.maxDuration > 0 || f.matchTitle != nil || f.rejectTitle != nil || f.expr != nil)
+}
+
+func (f *Filter) MaxDownloads() int {
+	if f == nil {
+		return 0
+	}
+	return f.maxDownloads
+}
+
+func (f *Filter) Apply(items []adapter.MediaInfo) []adapter.MediaInfo {
+	if f == nil {
+		return items
+	}
+	var result []adapter.MediaInfo
+	for _, i := range f.Select(items) {
+		result = append(result, items[i])
+	}
+	return f.Order(result)
+}
+
+func (f *Filter) Select(items []adapter.MediaInfo) []int {
+	var picked []int
+	for _, i := range f.positions(len(items)) {
+		if f.match(&items[i], true) {
+			picked = append(picked, i)
+		}
+	}
+	return picked
+}
+
+func (f *Filter) Order(items []adapter.MediaInfo) []adapter.MediaInfo {
+	if f != nil && f.reverse {
+		items = slices.Clone(items)
+		slices.Reverse(items)
+	}
+	return items
+}
+
+func (f *Filter) Match(info *adapter.MediaInfo) bool {
+	return f.match(info, false)
+}
+
+func (f *Filter) match(info *adapter.MediaInfo, partial bool) bool {
+	if f == nil {
+		return true
+	}
+	if info.Duration > 0 {
+		d := time.Duration(info.Duration) * time.Second
+		if f.minDuration > 0 && d < f.minDuration {
+			return false
+		}
+		if f.maxDuration > 0 && d > f.maxDuration {
+			return false
+		}
+	}
+	if !info.UploadDate.IsZero() {
+		day := info.UploadDate.UTC().Truncate(24 * time.Hour)
+		if !f.dateAfter.IsZero() && day.Before(f.dateAfter) {
+			return false
+		}
+		if !f.dateBefore.IsZero() && day.After(f.dateBefore) {
+			return false
+		}
+	}
+	if f.matchTitle != nil && !f.matchTitle.MatchString(info.Title) {
+		return false
+	}
+	if f.rejectTitle != nil && f.rejectTitle.MatchString(info.Title) {
+		return false
+	}
+	if f.expr != nil {
+		if !partial {
+			return f.expr.Match(Fields(info))
+		}
+		if matched, known := f.expr.Partial(Fields(info)); known {
+			return matched
+		}
+	}
+	return true
+}
+
+type span struct {
+	start, end, step int
+}
+
+func parseItems(spec string) ([]span, error) {
+	var spans []span
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		s, err := parseSpan(part)
+		if err != nil {
+			return nil, fmt.Errorf("items %q: %w", spec, err)
+		}
+		spans = append(spans, s)
+	}
+	return spans, nil
+}
+
+func parseSpan(part string) (span, error) {
+	atoi := func(s string, def int) (int, error) {
+		if s == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("invalid index %q", s)
+		}
+		return n, nil
+	}
+
+	if strings.Contains(part, ":") {
+		fields := strings.Split(part, ":")
+		if len(fields) > 3 {
+			return span{}, fmt.Errorf("invalid slice %q", part)
+		}
+		start, err := atoi(fields[0], 1)
+		if err != nil {
+			return span{}, err
+		}
+		end, err := atoi(fields[1], -1)
+		if err != nil {
+			return span{}, err
+		}
+		step := 1
+		if len(fields) == 3 {
+			if step, err = atoi(fields[2], 1); err != nil || step < 0 {
+				return span{}, fmt.Errorf("invalid step %q", fields[2])
+			}
+		}
+		return span{start: start, end: end, step: step}, nil
+	}
+
+	if i := strings.Index(part[1:], "-"); i >= 0 {
+		start, err := atoi(part[:i+1], 0)
+		if err != nil {
+			return span{}, err
+		}
+		end, err := atoi(part[i+2:], 0)
+		if err != nil {
+			return span{}, err
+		}
+		return span{start: start, end: end, step: 1}, nil
+	}
+
+	n, err := atoi(part, 0)
+	if err != nil {
+		return span{}, err
+	}
+	return span{start: n, end: n, step: 1}, nil
+}
+
+func (f *Filter) positions(n int) []int {
+	if len(f.items) == 0 {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	resolve := func(i int) int {
+		if i < 0 {
+			return n + i
+		}
+		return i - 1
+	}
+	seen := make([]bool, n)
+	var result []int
+	for _, s := range f.items {
+		start, end := max(resolve(s.start), 0), min(resolve(s.end), n-1)
+		for i := start; i <= end; i += s.step {
+			if !seen[i] {
+				seen[i] = true
+				result = append(result, i)
+			}
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+func parseDate(s string) (time.Time, error) {
+	if strings.TrimSpace(s) == "" {
+		return time.Time{}, nil
+	}
+	return match.ParseDate(s)
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	return match.ParseDuration(s)
+}
@@ -0,0 +1,197 @@
+package outfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	PartExt     = ".part"
+	PartMetaExt = ".meta"
+)
+
+type Policy string
+
+const (
+	Skip   Policy = "skip"
+	Force  Policy = "force"
+	Rename Policy = "rename"
+)
+
+var ErrExists = errors.New("file already exists")
+
+var (
+	claimMu sync.Mutex
+	claimed = make(map[string]bool)
+)
+
+type PartMeta struct {
+	Format string `json:"format"`
+	Size   int64  `json:"size"`
+}
+
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return Skip, nil
+	case Skip, Force, Rename:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid overwrite policy %q (want skip, force or rename)", s)
+}
+
+func PartPath(path string) string {
+	return path + PartExt
+}
+
+func PartMetaPath(part string) string {
+	return part + PartMetaExt
+}
+
+func ReadPartMeta(part string) (PartMeta, bool) {
+	var m PartMeta
+	data, err := os.ReadFile(PartMetaPath(part))
+	if err != nil || json.Unmarshal(data, &m) != nil {
+		return PartMeta{}, false
+	}
+	return m, m.Format != "" && m.Size > 0
+}
+
+func WritePartMeta(part string, m PartMeta) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return WriteFile(PartMetaPath(part), data, 0o644)
+}
+
+func Release(part string) {
+	claimMu.Lock()
+	defer claimMu.Unlock()
+	delete(claimed, strings.TrimSuffix(part, PartExt))
+}
+
+func Discard(part string) {
+	claimMu.Lock()
+	defer claimMu.Unlock()
+	_ = os.Remove(part)
+	release(part)
+}
+
+func release(part string) {
+	delete(claimed, strings.TrimSuffix(part, PartExt))
+	_ = os.Remove(PartMetaPath(part))
+}
+
+func (p Policy) Claim(path string, sidecars ...string) (string, error) {
+	claimMu.Lock()
+	defer claimMu.Unlock()
+
+	final, err := p.resolve(path, sidecars)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(final), 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(PartPath(final), os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return "", err
+	}
+	claimed[final] = true
+	return final, f.Close()
+}
+
+func (p Policy) Resolve(path string, sidecars ...string) (string, error) {
+	claimMu.Lock()
+	defer claimMu.Unlock()
+	return p.resolve(path, sidecars)
+}
+
+func (p Policy) resolve(path string, sidecars []string) (string, error) {
+	switch p {
+	case Force:
+		return path, nil
+	case Rename:
+		ext := filepath.Ext(path)
+		stem := strings.TrimSuffix(path, ext)
+		candidate := path
+		for n := 1; taken(candidate, sidecars); n++ {
+			candidate = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+		}
+		return candidate, nil
+	default:
+		if exists(path) {
+			return path, fmt.Errorf("%w: %s", ErrExists, path)
+		}
+		return path, nil
+	}
+}
+
+func taken(path string, sidecars []string) bool {
+	if exists(path) || claimed[path] {
+		return true
+	}
+	for _, ext := range sidecars {
+		if exists(path + ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+func (p Policy) Commit(part, path string, sidecars ...string) (string, error) {
+	claimMu.Lock()
+	defer claimMu.Unlock()
+	defer release(part)
+
+	if path != strings.TrimSuffix(part, PartExt) || exists(path) {
+		final, err := p.resolve(path, sidecars)
+		if err != nil {
+			_ = os.Remove(part)
+			return path, err
+		}
+		path = final
+	}
+	return path, Commit(part, path)
+}
+
+func Commit(part, path string) error {
+	if err := os.Rename(part, path); err != nil {
+		_ = os.Remove(part)
+		return err
+	}
+	return nil
+}
+
+func WriteFile(path string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return Commit(tmp.Name(), path)
+}
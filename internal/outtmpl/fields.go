@@ -0,0 +1,79 @@
+package outtmpl
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+)
+
+func InfoFields(info *adapter.MediaInfo) Fields {
+	f := Fields{
+		"id":           info.ID,
+		"title":        info.Title,
+		"fulltitle":    info.Title,
+		"description":  info.Description,
+		"type":         string(info.Type),
+		"url":          info.URL,
+		"webpage_url":  info.URL,
+		"platform":     info.Platform,
+		"extractor":    info.Platform,
+		"uploader":     info.Uploader,
+		"channel":      info.Uploader,
+		"uploader_id":  info.UploaderID,
+		"upload_date":  info.UploadDate,
+		"release_date": info.UploadDate,
+	}
+	if info.Duration > 0 {
+		f["duration"] = info.Duration
+		f["duration_string"] = FormatDuration(time.Duration(info.Duration) * time.Second)
+	}
+	if info.ViewCount > 0 {
+		f["view_count"] = info.ViewCount
+	}
+	return f
+}
+
+func (f Fields) WithPlaylist(playlist *adapter.MediaInfo, index int) Fields {
+	if playlist == nil {
+		return f
+	}
+	f["playlist_id"] = playlist.ID
+	f["playlist_title"] = playlist.Title
+	f["playlist"] = playlist.Title
+	f["playlist_uploader"] = playlist.Uploader
+	f["playlist_count"] = len(playlist.Items)
+	if index > 0 {
+		f["playlist_index"] = index
+	}
+	return f
+}
+
+func (f Fields) WithFormats(formats []adapter.Format, ext string) Fields {
+	f["ext"] = ext
+	if len(formats) == 0 {
+		return f
+	}
+
+	var ids []string
+	for _, format := range formats {
+		ids = append(ids, format.ID)
+		if format.IsVideo() {
+			f["height"] = format.Height
+			f["width"] = format.Width
+			f["resolution"] = format.Quality
+			f["vcodec"] = format.VideoCodec
+			if format.FPS > 0 {
+				f["fps"] = format.FPS
+			}
+		}
+		if format.AudioCodec != "" {
+			f["acodec"] = format.AudioCodec
+		}
+		if format.Language != "" {
+			f["language"] = format.Language
+		}
+	}
+	f["format_id"] = strings.Join(ids, "+")
+	return f
+}
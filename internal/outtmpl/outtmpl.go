@@ -0,0 +1,255 @@
+package outtmpl
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTemplate         = "{title} [{id}].{ext}"
+	DefaultPlaylistTemplate = "{playlist_title}/{playlist_index:03d} - {title} [{id}].{ext}"
+
+	MaxComponentLength = 200
+
+	missingValue = "NA"
+)
+
+type Fields map[string]any
+
+type Template struct {
+	src   string
+	parts []part
+}
+
+type part struct {
+	literal string
+	field   string
+	spec    string
+	def     string
+	hasDef  bool
+}
+
+func Parse(src string) (*Template, error) {
+	t := &Template{src: src}
+	var lit strings.Builder
+
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '{' && i+1 < len(src) && src[i+1] == '{':
+			lit.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(src) && src[i+1] == '}':
+			lit.WriteByte('}')
+			i++
+		case c == '}':
+			return nil, fmt.Errorf("template %q: unexpected '}' at %d (use '}}' for a literal brace)", src, i)
+		case c == '{':
+			end := strings.IndexByte(src[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("template %q: unterminated field at %d", src, i)
+			}
+			p, err := parseField(src[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("template %q: %w", src, err)
+			}
+			if lit.Len() > 0 {
+				t.parts = append(t.parts, part{literal: lit.String()})
+				lit.Reset()
+			}
+			t.parts = append(t.parts, p)
+			i += end
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	if lit.Len() > 0 {
+		t.parts = append(t.parts, part{literal: lit.String()})
+	}
+	return t, nil
+}
+
+func MustParse(src string) *Template {
+	t, err := Parse(src)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseField(s string) (part, error) {
+	var p part
+	s, p.def, p.hasDef = strings.Cut(s, "|")
+	p.field, p.spec, _ = strings.Cut(s, ":")
+	p.field = strings.TrimSpace(p.field)
+	if p.field == "" {
+		return p, fmt.Errorf("empty field name in {%s}", s)
+	}
+	for _, c := range p.field {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return p, fmt.Errorf("invalid field name %q", p.field)
+		}
+	}
+	return p, nil
+}
+
+func (t *Template) String() string {
+	return t.src
+}
+
+func (t *Template) Fields() []string {
+	var names []string
+	for _, p := range t.parts {
+		if p.field != "" {
+			names = append(names, p.field)
+		}
+	}
+	return names
+}
+
+func (t *Template) Render(fields Fields) string {
+	return t.render(fields, func(s string) string { return s })
+}
+
+func (t *Template) Path(fields Fields) string {
+	rendered := t.render(fields, SanitizeComponent)
+
+	parts := strings.Split(filepath.ToSlash(rendered), "/")
+	components := make([]string, 0, len(parts))
+	for i, c := range parts {
+		switch {
+		case i == 0 && c == "":
+			components = append(components, c)
+		case c == "":
+		case i == len(parts)-1:
+			components = append(components, truncateFilename(cleanComponent(c), MaxComponentLength))
+		default:
+			components = append(components, Truncate(cleanComponent(c), MaxComponentLength))
+		}
+	}
+	return filepath.FromSlash(strings.Join(components, "/"))
+}
+
+func (t *Template) render(fields Fields, escape func(string) string) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.field == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+		value, ok := format(fields[p.field], p.spec)
+		switch {
+		case ok:
+			b.WriteString(escape(value))
+		case p.hasDef:
+			b.WriteString(escape(p.def))
+		default:
+			b.WriteString(missingValue)
+		}
+	}
+	return b.String()
+}
+
+func format(v any, spec string) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		if v == "" {
+			return "", false
+		}
+	case time.Time:
+		if v.IsZero() {
+			return "", false
+		}
+		switch {
+		case spec == "":
+			return v.Format("20060102"), true
+		case strings.Contains(spec, "%"):
+			return strftime(v, spec), true
+		default:
+			return v.Format(spec), true
+		}
+	case time.Duration:
+		if spec == "" {
+			return FormatDuration(v), true
+		}
+		return format(int64(v.Seconds()), spec)
+	}
+
+	if spec == "" {
+		return fmt.Sprint(v), true
+	}
+	switch verb := spec[len(spec)-1]; verb {
+	case 'd', 'x', 'X', 'o', 'b':
+		if s, ok := v.(string); ok {
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				v = n
+			}
+		}
+	case 'f', 'e', 'g':
+		if s, ok := v.(string); ok {
+			if n, err := strconv.ParseFloat(s, 64); err == nil {
+				v = n
+			}
+		}
+	case 's', 'q', 'v':
+	default:
+		spec += "v"
+	}
+	return fmt.Sprintf("%"+spec, v), true
+}
+
+var strftimeVerbs = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'H': "15",
+	'M': "04",
+	'S': "05",
+	'b': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'p': "PM",
+	'Z': "MST",
+	'z': "-0700",
+}
+
+func strftime(t time.Time, spec string) string {
+	var b strings.Builder
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != '%' || i+1 == len(spec) {
+			b.WriteByte(spec[i])
+			continue
+		}
+		i++
+		switch c := spec[i]; c {
+		case '%':
+			b.WriteByte('%')
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		default:
+			if layout, ok := strftimeVerbs[c]; ok {
+				b.WriteString(t.Format(layout))
+			} else {
+				b.WriteByte('%')
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
+
+func FormatDuration(d time.Duration) string {
+	s := int64(d.Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s%3600/60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -0,0 +1,79 @@
+package outtmpl
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var componentReplacer = strings.NewReplacer(
+	"/", "-",
+	"\\", "-",
+	":", "-",
+	"*", "",
+	"?", "",
+	"\"", "",
+	"<", "",
+	">", "",
+	"|", "",
+)
+
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+func SanitizeComponent(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	s = componentReplacer.Replace(s)
+	if s == "." || s == ".." {
+		return strings.Repeat("_", len(s))
+	}
+	return s
+}
+
+func cleanComponent(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "." || s == ".." {
+		return s
+	}
+	s = strings.TrimRight(s, ". ")
+	if s == "" {
+		return "_"
+	}
+	stem, _, _ := strings.Cut(s, ".")
+	if reservedNames[strings.ToUpper(stem)] {
+		s = "_" + s
+	}
+	return s
+}
+
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return strings.TrimSpace(s[:n])
+}
+
+func truncateFilename(name string, n int) string {
+	if len(name) <= n {
+		return name
+	}
+	ext := ""
+	if i := strings.LastIndexByte(name, '.'); i > 0 && len(name)-i <= 16 {
+		name, ext = name[:i], name[i:]
+	}
+	return Truncate(name, n-len(ext)) + ext
+}
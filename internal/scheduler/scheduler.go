@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type Task struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type Result struct {
+	Index int
+	Name  string
+	Err   error
+}
+
+type Pool struct {
+	workers int
+}
+
+func New(workers int) *Pool {
+	return &Pool{workers: max(workers, 1)}
+}
+
+func (p *Pool) Workers() int {
+	return p.workers
+}
+
+func (p *Pool) Run(ctx context.Context, tasks []Task, onResult func(Result)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan Result, len(tasks))
+	for i := range results {
+		results[i] = make(chan Result, 1)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(p.workers, len(tasks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] <- Result{Index: i, Name: tasks[i].Name, Err: tasks[i].Run(ctx)}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range tasks {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				for j := i; j < len(tasks); j++ {
+					results[j] <- Result{Index: j, Name: tasks[j].Name, Err: ctx.Err()}
+				}
+				return
+			}
+		}
+	}()
+
+	var errs []error
+	for i := range tasks {
+		r := <-results[i]
+		if onResult != nil {
+			onResult(r)
+		}
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Name, r.Err))
+		}
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,93 @@
+package sniff
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const HeaderSize = 512
+
+var ErrNotMedia = errors.New("response is not media")
+
+func Detect(head []byte) (string, error) {
+	switch {
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		return mp4Extension(head[8:12]), nil
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		if bytes.Contains(head[:min(len(head), 64)], []byte("webm")) {
+			return "webm", nil
+		}
+		return "mkv", nil
+	case bytes.HasPrefix(head, []byte("OggS")):
+		if bytes.Contains(head, []byte("OpusHead")) {
+			return "opus", nil
+		}
+		return "ogg", nil
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return "mp3", nil
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		if head[1]&0x06 == 0 {
+			return "aac", nil
+		}
+		return "mp3", nil
+	case isTransportStream(head):
+		return "ts", nil
+	}
+
+	if kind := textKind(head); kind != "" {
+		return "", fmt.Errorf("%w: got %s", ErrNotMedia, kind)
+	}
+	return "", nil
+}
+
+func Peek(r io.Reader) (string, io.Reader, error) {
+	br := bufio.NewReaderSize(r, HeaderSize)
+	head, err := br.Peek(HeaderSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return "", br, err
+	}
+	if len(head) == 0 {
+		return "", br, fmt.Errorf("%w: empty response", ErrNotMedia)
+	}
+	ext, err := Detect(head)
+	return ext, br, err
+}
+
+func mp4Extension(brand []byte) string {
+	switch string(brand) {
+	case "M4A ", "M4B ":
+		return "m4a"
+	case "qt  ":
+		return "mov"
+	}
+	if bytes.HasPrefix(brand, []byte("3gp")) {
+		return "3gp"
+	}
+	return "mp4"
+}
+
+func isTransportStream(head []byte) bool {
+	const packet = 188
+	if len(head) <= packet || head[0] != 0x47 || head[packet] != 0x47 {
+		return false
+	}
+	return len(head) <= 2*packet || head[2*packet] == 0x47
+}
+
+func textKind(head []byte) string {
+	text := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xEF\xBB\xBF")), " \t\r\n")
+	lower := bytes.ToLower(text[:min(len(text), 16)])
+	switch {
+	case bytes.HasPrefix(lower, []byte("<!doctype html")), bytes.HasPrefix(lower, []byte("<html")),
+		bytes.HasPrefix(lower, []byte("<head")), bytes.HasPrefix(lower, []byte("<body")):
+		return "an HTML page"
+	case bytes.HasPrefix(lower, []byte("<?xml")):
+		return "an XML document"
+	case bytes.HasPrefix(text, []byte("{")):
+		return "a JSON document"
+	}
+	return ""
+}
@@ -0,0 +1,5 @@
+//go:build !unix
+
+package graphics
+
+func cellSize() (int, int, bool) { return 0, 0, false }
@@ -0,0 +1,17 @@
+//go:build unix
+
+package graphics
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func cellSize() (int, int, bool) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+		return 0, 0, false
+	}
+	return int(ws.Xpixel / ws.Col), int(ws.Ypixel / ws.Row), true
+}
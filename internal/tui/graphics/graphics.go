@@ -0,0 +1,67 @@
+package graphics
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+type Protocol int
+
+const (
+	None Protocol = iota
+	HalfBlocks
+	Sixel
+	ITerm2
+	Kitty
+)
+
+var protocolNames = map[Protocol]string{
+	None:       "none",
+	HalfBlocks: "blocks",
+	Sixel:      "sixel",
+	ITerm2:     "iterm2",
+	Kitty:      "kitty",
+}
+
+func (p Protocol) String() string {
+	return protocolNames[p]
+}
+
+func ParseProtocol(s string) (Protocol, error) {
+	for p, name := range protocolNames {
+		if strings.EqualFold(s, name) {
+			return p, nil
+		}
+	}
+	return None, fmt.Errorf("unknown graphics protocol %q (want kitty, iterm2, sixel, blocks or none)", s)
+}
+
+func Detect() Protocol {
+	if s := os.Getenv("AIODL_GRAPHICS"); s != "" {
+		if p, err := ParseProtocol(s); err == nil {
+			return p
+		}
+	}
+	if lipgloss.ColorProfile() == termenv.Ascii {
+		return None
+	}
+
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("TMUX") != "" || strings.HasPrefix(term, "screen"):
+		return HalfBlocks
+	case os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || term == "xterm-ghostty" || program == "ghostty":
+		return Kitty
+	case program == "iTerm.app" || program == "WezTerm" || os.Getenv("LC_TERMINAL") == "iTerm2":
+		return ITerm2
+	case strings.Contains(term, "sixel") || strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "mlterm") ||
+		os.Getenv("WT_SESSION") != "":
+		return Sixel
+	}
+	return HalfBlocks
+}
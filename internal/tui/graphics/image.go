@@ -0,0 +1,135 @@
+package graphics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"sync"
+)
+
+const (
+	maxImageSize = 8 << 20
+	cacheSize    = 64
+)
+
+type Image struct {
+	image.Image
+	Data []byte
+	id   uint32
+}
+
+func Decode(data []byte) (*Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	if b := img.Bounds(); b.Dx() == 0 || b.Dy() == 0 {
+		return nil, errors.New("decode image: empty image")
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write(data)
+	id := h.Sum32() & 0xffffff
+	if id == 0 {
+		id = 1
+	}
+	return &Image{Image: img, Data: data, id: id}, nil
+}
+
+type Loader struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*Image
+	order []string
+}
+
+func NewLoader(client *http.Client) *Loader {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Loader{client: client, cache: make(map[string]*Image)}
+}
+
+func (l *Loader) Load(ctx context.Context, url string) (*Image, error) {
+	l.mu.Lock()
+	img, ok := l.cache[url]
+	l.mu.Unlock()
+	if ok {
+		return img, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch image: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch image: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch image: %w", err)
+	}
+	if len(data) > maxImageSize {
+		return nil, errors.New("fetch image: image too large")
+	}
+	if img, err = Decode(data); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache[url]; !ok {
+		if len(l.order) >= cacheSize {
+			delete(l.cache, l.order[0])
+			l.order = l.order[1:]
+		}
+		l.order = append(l.order, url)
+	}
+	l.cache[url] = img
+	return img, nil
+}
+
+func resize(src image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	b := src.Bounds()
+	for y := range height {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/height, y0+1)
+		for x := range width {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(b.Min.X+(x+1)*b.Dx()/width, x0+1)
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+pr, g+pg, bl+pb, a+pa
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
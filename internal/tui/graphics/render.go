@@ -0,0 +1,178 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/iterm2"
+	"github.com/charmbracelet/x/ansi/kitty"
+	"github.com/charmbracelet/x/ansi/sixel"
+)
+
+const (
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+type Block struct {
+	Lines []string
+	Cols  int
+	Rows  int
+}
+
+func (b Block) String() string {
+	return strings.Join(b.Lines, "\n")
+}
+
+type Renderer struct {
+	protocol   Protocol
+	cellWidth  int
+	cellHeight int
+}
+
+func NewRenderer(protocol Protocol) *Renderer {
+	r := &Renderer{protocol: protocol, cellWidth: defaultCellWidth, cellHeight: defaultCellHeight}
+	if w, h, ok := cellSize(); ok {
+		r.cellWidth, r.cellHeight = w, h
+	}
+	return r
+}
+
+func (r *Renderer) Protocol() Protocol {
+	if r == nil {
+		return None
+	}
+	return r.protocol
+}
+
+func (r *Renderer) Fit(img image.Image, maxCols, maxRows int) (int, int) {
+	b := img.Bounds()
+	aspect := float64(b.Dx()) / float64(b.Dy())
+	cellAspect := float64(r.cellWidth) / float64(r.cellHeight)
+
+	cols := maxCols
+	rows := int(float64(cols)*cellAspect/aspect + 0.5)
+	if rows > maxRows {
+		rows = maxRows
+		cols = int(float64(rows)*aspect/cellAspect + 0.5)
+	}
+	return max(min(cols, maxCols), 1), max(rows, 1)
+}
+
+func (r *Renderer) Render(img *Image, maxCols, maxRows int) Block {
+	if r.Protocol() == None || img == nil || maxCols <= 0 || maxRows <= 0 {
+		return Block{}
+	}
+
+	cols, rows := r.Fit(img, maxCols, maxRows)
+	switch r.protocol {
+	case Kitty:
+		return r.kitty(img, cols, rows)
+	case ITerm2:
+		return inline(ansi.ITerm2(iterm2.File{
+			Name:            "thumbnail",
+			Size:            int64(len(img.Data)),
+			Width:           iterm2.Cells(cols),
+			Height:          iterm2.Cells(rows),
+			Inline:          true,
+			DoNotMoveCursor: true,
+			Content:         []byte(base64.StdEncoding.EncodeToString(img.Data)),
+		}), cols, rows)
+	case Sixel:
+		return inline(r.sixel(img, cols, rows), cols, rows)
+	default:
+		return halfBlocks(img, cols, rows)
+	}
+}
+
+func Blank(cols, rows int) Block {
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = strings.Repeat(" ", cols)
+	}
+	return Block{Lines: lines, Cols: cols, Rows: rows}
+}
+
+func inline(seq string, cols, rows int) Block {
+	pad := ansi.CursorForward(cols)
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = pad
+	}
+	lines[0] = ansi.SaveCursor + seq + ansi.RestoreCursor + pad
+	return Block{Lines: lines, Cols: cols, Rows: rows}
+}
+
+func (r *Renderer) sixel(img *Image, cols, rows int) string {
+	var buf bytes.Buffer
+	enc := sixel.Encoder{}
+	_ = enc.Encode(&buf, resize(img, cols*r.cellWidth, rows*r.cellHeight))
+	return ansi.SixelGraphics(0, 1, 0, buf.Bytes())
+}
+
+func (r *Renderer) kitty(img *Image, cols, rows int) Block {
+	var src image.Image = img
+	if b := img.Bounds(); b.Dx() > cols*r.cellWidth || b.Dy() > rows*r.cellHeight {
+		src = resize(img, cols*r.cellWidth, rows*r.cellHeight)
+	}
+
+	var transmit strings.Builder
+	err := kitty.EncodeGraphics(&transmit, src, &kitty.Options{
+		Action:           kitty.TransmitAndPut,
+		Quite:            2,
+		ID:               int(img.id),
+		Format:           kitty.PNG,
+		Transmission:     kitty.Direct,
+		Columns:          cols,
+		Rows:             rows,
+		VirtualPlacement: true,
+		Chunk:            true,
+	})
+	if err != nil {
+		return halfBlocks(img, cols, rows)
+	}
+
+	fg := fmt.Sprintf("\x1b[38;2;%d;%d;%dm", img.id>>16&0xff, img.id>>8&0xff, img.id&0xff)
+	lines := make([]string, rows)
+	for y := range rows {
+		var b strings.Builder
+		if y == 0 {
+			b.WriteString(transmit.String())
+		}
+		b.WriteString(fg)
+		for x := range cols {
+			b.WriteRune(kitty.Placeholder)
+			b.WriteRune(kitty.Diacritic(y))
+			b.WriteRune(kitty.Diacritic(x))
+		}
+		b.WriteString("\x1b[39m")
+		lines[y] = b.String()
+	}
+	return Block{Lines: lines, Cols: cols, Rows: rows}
+}
+
+func halfBlocks(img image.Image, cols, rows int) Block {
+	px := resize(img, cols, rows*2)
+	lines := make([]string, rows)
+	for y := range rows {
+		var b strings.Builder
+		for x := range cols {
+			style := lipgloss.NewStyle().
+				Foreground(hexColor(px.RGBAAt(x, y*2))).
+				Background(hexColor(px.RGBAAt(x, y*2+1)))
+			b.WriteString(style.Render("▀"))
+		}
+		lines[y] = b.String()
+	}
+	return Block{Lines: lines, Cols: cols, Rows: rows}
+}
+
+func hexColor(c color.RGBA) lipgloss.Color {
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))
+}
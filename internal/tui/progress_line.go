@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const progressLineInterval = 100 * time.Millisecond
+
+type progressState struct {
+	downloaded int64
+	total      int64
+}
+
+type ProgressLine struct {
+	mu     sync.Mutex
+	w      io.Writer
+	active map[int]progressState
+	done   int
+	total  int
+	last   time.Time
+	width  int
+}
+
+func NewProgressLine(w io.Writer, total int) *ProgressLine {
+	return &ProgressLine{w: w, active: make(map[int]progressState), total: total}
+}
+
+func (p *ProgressLine) Update(id int, downloaded, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active[id] = progressState{downloaded: downloaded, total: total}
+	if time.Since(p.last) < progressLineInterval {
+		return
+	}
+	p.last = time.Now()
+	p.draw()
+}
+
+func (p *ProgressLine) Finish(id int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.active, id)
+	p.done++
+	p.draw()
+}
+
+func (p *ProgressLine) Println(f func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	f()
+	p.draw()
+}
+
+func (p *ProgressLine) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+func (p *ProgressLine) draw() {
+	if len(p.active) == 0 {
+		p.clear()
+		return
+	}
+
+	var downloaded, total int64
+	for _, s := range p.active {
+		downloaded += s.downloaded
+		total += s.total
+	}
+	line := fmt.Sprintf("[%d/%d] %d active · %s", p.done, p.total, len(p.active), FormatBytes(downloaded))
+	if total > 0 {
+		line += fmt.Sprintf(" / %s (%.1f%%)", FormatBytes(total), float64(downloaded)/float64(total)*100)
+	}
+
+	width := utf8.RuneCountInString(line)
+	pad := max(p.width-width, 0)
+	_, _ = fmt.Fprint(p.w, "\r"+line+strings.Repeat(" ", pad))
+	p.width = width
+}
+
+func (p *ProgressLine) clear() {
+	if p.width == 0 {
+		return
+	}
+	_, _ = fmt.Fprint(p.w, "\r"+strings.Repeat(" ", p.width)+"\r")
+	p.width = 0
+}
@@ -0,0 +1,219 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+const DefaultName = "default"
+
+type Palette struct {
+	Accent        string
+	Muted         string
+	Selection     string
+	Success       string
+	Warning       string
+	Error         string
+	ProgressStart string
+	ProgressEnd   string
+}
+
+var palettes = map[string]Palette{
+	DefaultName: {
+		Selection:     "212",
+		Success:       "2",
+		Warning:       "3",
+		Error:         "1",
+		ProgressStart: "#5A56E0",
+		ProgressEnd:   "#EE6FF8",
+	},
+	"mono": {},
+	"dracula": {
+		Accent:        "#bd93f9",
+		Muted:         "#6272a4",
+		Selection:     "#ff79c6",
+		Success:       "#50fa7b",
+		Warning:       "#f1fa8c",
+		Error:         "#ff5555",
+		ProgressStart: "#bd93f9",
+		ProgressEnd:   "#ff79c6",
+	},
+	"nord": {
+		Accent:        "#88c0d0",
+		Muted:         "#616e88",
+		Selection:     "#8fbcbb",
+		Success:       "#a3be8c",
+		Warning:       "#ebcb8b",
+		Error:         "#bf616a",
+		ProgressStart: "#5e81ac",
+		ProgressEnd:   "#88c0d0",
+	},
+	"solarized": {
+		Accent:        "#268bd2",
+		Muted:         "#586e75",
+		Selection:     "#2aa198",
+		Success:       "#859900",
+		Warning:       "#b58900",
+		Error:         "#dc322f",
+		ProgressStart: "#268bd2",
+		ProgressEnd:   "#2aa198",
+	},
+	"gruvbox": {
+		Accent:        "#fabd2f",
+		Muted:         "#928374",
+		Selection:     "#fe8019",
+		Success:       "#b8bb26",
+		Warning:       "#fe8019",
+		Error:         "#fb4934",
+		ProgressStart: "#83a598",
+		ProgressEnd:   "#8ec07c",
+	},
+}
+
+var colorNames = []string{"accent", "muted", "selection", "success", "warning", "error", "progress_start", "progress_end"}
+
+func (p *Palette) color(name string) *string {
+	switch name {
+	case "accent":
+		return &p.Accent
+	case "muted":
+		return &p.Muted
+	case "selection":
+		return &p.Selection
+	case "success":
+		return &p.Success
+	case "warning":
+		return &p.Warning
+	case "error":
+		return &p.Error
+	case "progress_start":
+		return &p.ProgressStart
+	case "progress_end":
+		return &p.ProgressEnd
+	}
+	return nil
+}
+
+type Theme struct {
+	Name     string
+	Palette  Palette
+	Title    lipgloss.Style
+	Muted    lipgloss.Style
+	Accent   lipgloss.Style
+	Selected lipgloss.Style
+	Success  lipgloss.Style
+	Warning  lipgloss.Style
+	Error    lipgloss.Style
+}
+
+func Names() []string {
+	names := make([]string, 0, len(palettes))
+	for name := range palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Default() Theme {
+	t, _ := New(DefaultName, nil)
+	return t
+}
+
+func New(name string, colors map[string]string) (Theme, error) {
+	if name == "" {
+		name = DefaultName
+	}
+	name = strings.ToLower(name)
+	palette, ok := palettes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (want %s)", name, strings.Join(Names(), ", "))
+	}
+
+	for _, key := range sortedKeys(colors) {
+		field := palette.color(strings.ToLower(key))
+		if field == nil {
+			return Theme{}, fmt.Errorf("unknown color %q (want %s)", key, strings.Join(colorNames, ", "))
+		}
+		value := strings.TrimSpace(colors[key])
+		if err := validateColor(value); err != nil {
+			return Theme{}, fmt.Errorf("color %s: %w", key, err)
+		}
+		*field = value
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		palette = Palette{}
+	}
+	return build(name, palette), nil
+}
+
+func build(name string, p Palette) Theme {
+	t := Theme{
+		Name:     name,
+		Palette:  p,
+		Title:    foreground(lipgloss.NewStyle().Bold(true), p.Accent),
+		Accent:   foreground(lipgloss.NewStyle().Bold(true), p.Accent),
+		Selected: foreground(lipgloss.NewStyle().Bold(true), p.Selection),
+		Success:  foreground(lipgloss.NewStyle(), p.Success),
+		Warning:  foreground(lipgloss.NewStyle(), p.Warning),
+		Error:    foreground(lipgloss.NewStyle(), p.Error),
+	}
+	if p.Muted != "" {
+		t.Muted = lipgloss.NewStyle().Foreground(lipgloss.Color(p.Muted))
+	} else {
+		t.Muted = lipgloss.NewStyle().Faint(true)
+	}
+	if p.Selection == "" {
+		t.Selected = t.Selected.Reverse(true)
+	}
+	return t
+}
+
+func (t Theme) Progress() []progress.Option {
+	switch {
+	case t.Palette.ProgressStart == "" || lipgloss.ColorProfile() == termenv.Ascii:
+		return []progress.Option{progress.WithSolidFill(""), progress.WithColorProfile(termenv.Ascii)}
+	case t.Palette.ProgressEnd == "":
+		return []progress.Option{progress.WithSolidFill(t.Palette.ProgressStart)}
+	default:
+		return []progress.Option{progress.WithGradient(t.Palette.ProgressStart, t.Palette.ProgressEnd)}
+	}
+}
+
+func foreground(s lipgloss.Style, color string) lipgloss.Style {
+	if color == "" {
+		return s
+	}
+	return s.Foreground(lipgloss.Color(color))
+}
+
+func validateColor(s string) error {
+	if strings.HasPrefix(s, "#") {
+		hex := s[1:]
+		if len(hex) == 3 || len(hex) == 6 {
+			if _, err := strconv.ParseUint(hex, 16, 32); err == nil {
+				return nil
+			}
+		}
+	} else if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return nil
+	}
+	return fmt.Errorf("invalid color %q (want #rrggbb, #rgb or an ANSI color 0-255)", s)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
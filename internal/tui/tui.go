@@ -4,9 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/signal"
 	"strconv"
-	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -35,7 +33,7 @@ func detectMode() Mode {
 	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
 		return ModeNonInteractive
 	}
-	if os.Getenv("CI") != "" || os.Getenv("NO_COLOR") != "" {
+	if os.Getenv("CI") != "" {
 		return ModeNonInteractive
 	}
 	return ModeInteractive
@@ -53,18 +51,19 @@ func (u *UI) IsInteractive() bool {
 	return u.mode == ModeInteractive
 }
 
-func (u *UI) Run(model tea.Model) (tea.Model, error) {
+func (u *UI) Run(ctx context.Context, model tea.Model) (tea.Model, error) {
 	if u.mode == ModeNonInteractive {
 		return model, nil
 	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer cancel()
-
-	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx))
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx), tea.WithoutSignalHandler())
 	return p.Run()
 }
 
+func (u *UI) Program(ctx context.Context, model tea.Model) *tea.Program {
+	return tea.NewProgram(model, tea.WithContext(ctx), tea.WithoutSignalHandler())
+}
+
 func FormatSize(size int64) string {
 	if size <= 0 {
 		return "-"
@@ -0,0 +1,497 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+)
+
+type BackMsg struct{}
+
+type FormatSelectedMsg struct {
+	Format *adapter.Format
+	Audio  *adapter.Format
+}
+
+type PlaylistSelectedMsg struct {
+	Items []adapter.MediaInfo
+}
+
+func finish(embedded bool, msg tea.Msg) tea.Cmd {
+	if !embedded {
+		return tea.Quit
+	}
+	return func() tea.Msg { return msg }
+}
+
+type DownloadRequest struct {
+	Info   *adapter.MediaInfo
+	Format *adapter.Format
+	Audio  *adapter.Format
+	Items  []adapter.MediaInfo
+	Output string
+}
+
+type AppBackend struct {
+	Fetch      func(ctx context.Context, url string) (*adapter.MediaInfo, error)
+	OutputPath func(info *adapter.MediaInfo, format, audio *adapter.Format) string
+	Download   func(ctx context.Context, req DownloadRequest, send func(tea.Msg)) tea.Model
+}
+
+type appScreen int
+
+const (
+	screenURL appScreen = iota
+	screenFetching
+	screenInfo
+	screenFormats
+	screenPlaylist
+	screenOutput
+	screenDownload
+)
+
+const appHeaderHeight = 2
+
+type appInfoMsg struct {
+	url  string
+	info *adapter.MediaInfo
+	err  error
+}
+
+type appStream struct {
+	ch      chan tea.Msg
+	abandon chan struct{}
+}
+
+type appStreamMsg struct {
+	stream *appStream
+	msg    tea.Msg
+}
+
+func newAppStream() *appStream {
+	return &appStream{ch: make(chan tea.Msg), abandon: make(chan struct{})}
+}
+
+func (s *appStream) send(msg tea.Msg) {
+	select {
+	case s.ch <- msg:
+	case <-s.abandon:
+	}
+}
+
+func (s *appStream) listen() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case msg := <-s.ch:
+			return appStreamMsg{stream: s, msg: msg}
+		case <-s.abandon:
+			return nil
+		}
+	}
+}
+
+type appKeyMap struct {
+	Confirm  key.Binding
+	Download key.Binding
+	Back     key.Binding
+	Quit     key.Binding
+}
+
+func (k appKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Confirm, k.Download, k.Back, k.Quit}
+}
+
+func (k appKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+var appKeys = appKeyMap{
+	Confirm:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "continue")),
+	Download: key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "download best")),
+	Back:     key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+	Quit:     key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+}
+
+type AppModel struct {
+	ctx     context.Context
+	backend AppBackend
+
+	screen  appScreen
+	url     textinput.Model
+	output  textinput.Model
+	spinner spinner.Model
+	help    help.Model
+	keys    appKeyMap
+	preview *Preview
+
+	info   *adapter.MediaInfo
+	format *adapter.Format
+	audio  *adapter.Format
+	items  []adapter.MediaInfo
+	child  tea.Model
+	stream *appStream
+	cancel context.CancelFunc
+	err    error
+
+	width  int
+	height int
+}
+
+func NewApp(ctx context.Context, backend AppBackend) AppModel {
+	url := textinput.New()
+	url.Placeholder = "https://www.youtube.com/watch?v=..."
+	url.Prompt = "URL: "
+	url.Focus()
+
+	output := textinput.New()
+	output.Prompt = "Save to: "
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = styles.Accent
+
+	return AppModel{
+		ctx:     ctx,
+		backend: backend,
+		url:     url,
+		output:  output,
+		spinner: s,
+		help:    help.New(),
+		keys:    appKeys,
+	}
+}
+
+func (m AppModel) WithPreview(preview *Preview) AppModel {
+	m.preview = preview
+	return m
+}
+
+func (m AppModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.help.Width = msg.Width
+		m.url.Width = max(msg.Width-len(m.url.Prompt)-2, 10)
+		m.output.Width = max(msg.Width-len(m.output.Prompt)-2, 10)
+		return m.updateChild(m.childSize())
+
+	case tea.KeyMsg:
+		if key.Matches(msg, m.keys.Quit) {
+			m.stop()
+			return m, tea.Quit
+		}
+
+	case appStreamMsg:
+		if msg.stream != m.stream {
+			return m, nil
+		}
+		m, cmd := m.updateChild(msg.msg)
+		return m, tea.Batch(cmd, m.stream.listen())
+
+	case appInfoMsg:
+		if m.screen != screenFetching || msg.url != m.url.Value() {
+			return m, nil
+		}
+		m.cancel = nil
+		if msg.err != nil {
+			m.err = msg.err
+			m.screen = screenURL
+			return m, nil
+		}
+		m.info, m.format, m.audio, m.items = msg.info, nil, nil, nil
+		m.screen = screenInfo
+		return m, m.preview.Load(msg.info)
+
+	case ThumbnailMsg:
+		m.preview.Update(msg)
+		return m, nil
+
+	case BackMsg:
+		switch m.screen {
+		case screenFormats, screenPlaylist:
+			m.child = nil
+			m.screen = screenInfo
+		case screenDownload:
+			m.stop()
+			return m.reset()
+		}
+		return m, nil
+
+	case FormatSelectedMsg:
+		if m.screen == screenFormats {
+			m.format, m.audio = msg.Format, msg.Audio
+			return m.showOutput()
+		}
+		return m, nil
+
+	case PlaylistSelectedMsg:
+		if m.screen == screenPlaylist {
+			if len(msg.Items) == 0 {
+				m.err = errors.New("no items selected")
+				return m, nil
+			}
+			m.items = msg.Items
+			return m.showOutput()
+		}
+		return m, nil
+	}
+
+	switch m.screen {
+	case screenURL:
+		return m.updateURL(msg)
+	case screenFetching:
+		if k, ok := msg.(tea.KeyMsg); ok && key.Matches(k, m.keys.Back) {
+			m.stop()
+			m.screen = screenURL
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case screenInfo:
+		return m.updateInfo(msg)
+	case screenOutput:
+		return m.updateOutput(msg)
+	default:
+		if _, ok := msg.(tea.KeyMsg); ok {
+			m.err = nil
+		}
+		return m.updateChild(msg)
+	}
+}
+
+func (m AppModel) updateURL(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(k, m.keys.Confirm):
+			url := strings.TrimSpace(m.url.Value())
+			if url == "" {
+				return m, nil
+			}
+			m.url.SetValue(url)
+			m.err = nil
+			m.screen = screenFetching
+
+			ctx, cancel := context.WithCancel(m.ctx)
+			m.cancel = cancel
+			fetch := func() tea.Msg {
+				info, err := m.backend.Fetch(ctx, url)
+				return appInfoMsg{url: url, info: info, err: err}
+			}
+			return m, tea.Batch(fetch, m.spinner.Tick)
+		case key.Matches(k, m.keys.Back):
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.url, cmd = m.url.Update(msg)
+	return m, cmd
+}
+
+func (m AppModel) updateInfo(msg tea.Msg) (tea.Model, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(k, m.keys.Back):
+		return m.reset()
+	case key.Matches(k, m.keys.Download):
+		m.format, m.audio = nil, nil
+		if m.info.Type == adapter.MediaTypePlaylist {
+			m.items = m.info.Items
+		}
+		return m.showOutput()
+	case key.Matches(k, m.keys.Confirm):
+		switch {
+		case m.info.Type == adapter.MediaTypePlaylist:
+			m.child = NewPlaylistBrowser(fmt.Sprintf("Select videos from: %s", m.info.Title), m.info.Items).
+				WithPreview(m.preview).WithDetails(m.backend.Fetch).Embedded()
+			m.screen = screenPlaylist
+		case len(m.info.Formats) > 0:
+			m.child = NewFormatSelector(fmt.Sprintf("Select format for: %s", m.info.Title), m.info.Formats).
+				WithDuration(m.info.Duration).Embedded()
+			m.screen = screenFormats
+		default:
+			return m.showOutput()
+		}
+		m, cmd := m.updateChild(m.childSize())
+		return m, tea.Batch(m.child.Init(), cmd)
+	}
+	return m, nil
+}
+
+func (m AppModel) showOutput() (tea.Model, tea.Cmd) {
+	m.child = nil
+	m.screen = screenOutput
+	m.output.SetValue(m.backend.OutputPath(m.info, m.format, m.audio))
+	m.output.CursorEnd()
+	return m, m.output.Focus()
+}
+
+func (m AppModel) updateOutput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(k, m.keys.Back):
+			m.output.Blur()
+			m.screen = screenInfo
+			return m, nil
+		case key.Matches(k, m.keys.Confirm):
+			output := strings.TrimSpace(m.output.Value())
+			if output == "" {
+				return m, nil
+			}
+			m.output.Blur()
+
+			ctx, cancel := context.WithCancel(m.ctx)
+			m.cancel = cancel
+			m.stream = newAppStream()
+			m.child = m.backend.Download(ctx, DownloadRequest{
+				Info:   m.info,
+				Format: m.format,
+				Audio:  m.audio,
+				Items:  m.items,
+				Output: output,
+			}, m.stream.send)
+			m.screen = screenDownload
+
+			m, cmd := m.updateChild(m.childSize())
+			return m, tea.Batch(m.child.Init(), cmd, m.stream.listen())
+		}
+	}
+	var cmd tea.Cmd
+	m.output, cmd = m.output.Update(msg)
+	return m, cmd
+}
+
+func (m AppModel) updateChild(msg tea.Msg) (AppModel, tea.Cmd) {
+	if m.child == nil {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.child, cmd = m.child.Update(msg)
+	return m, cmd
+}
+
+func (m AppModel) childSize() tea.WindowSizeMsg {
+	return tea.WindowSizeMsg{Width: m.width, Height: max(m.height-appHeaderHeight, 1)}
+}
+
+func (m AppModel) reset() (tea.Model, tea.Cmd) {
+	m.child = nil
+	m.info, m.format, m.audio, m.items = nil, nil, nil, nil
+	m.screen = screenURL
+	m.url.SetValue("")
+	return m, m.url.Focus()
+}
+
+func (m *AppModel) stop() {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	if m.stream != nil {
+		close(m.stream.abandon)
+		m.stream = nil
+	}
+}
+
+func (m AppModel) Close() {
+	m.stop()
+}
+
+func (m AppModel) View() string {
+	var b strings.Builder
+
+	titleStyle := styles.Title
+	faintStyle := styles.Muted
+	errorStyle := styles.Error
+
+	b.WriteString(titleStyle.Render("aiodl") + faintStyle.Render(" · "+m.breadcrumb()) + "\n\n")
+
+	switch m.screen {
+	case screenURL:
+		b.WriteString("Paste a URL and press enter.\n\n")
+		b.WriteString(m.url.View() + "\n\n")
+		if m.err != nil {
+			b.WriteString(errorStyle.Render(m.err.Error()) + "\n\n")
+		}
+		b.WriteString(m.help.ShortHelpView([]key.Binding{m.keys.Confirm, withDesc(m.keys.Back, "quit")}))
+
+	case screenFetching:
+		b.WriteString(m.spinner.View() + " Fetching " + m.url.Value() + "\n\n")
+		b.WriteString(m.help.ShortHelpView([]key.Binding{m.keys.Back, m.keys.Quit}))
+
+	case screenInfo:
+		if m.width >= infoThumbCols+40 {
+			b.WriteString(m.preview.RenderInfo(m.info))
+		} else {
+			b.WriteString(RenderInfo(m.info))
+		}
+		if m.info.Type == adapter.MediaTypePlaylist && len(m.info.Items) > 0 {
+			b.WriteString("\n" + RenderPlaylistItems(m.info.Items, 5))
+		}
+		confirm := withDesc(m.keys.Confirm, "choose format")
+		if m.info.Type == adapter.MediaTypePlaylist {
+			confirm = withDesc(m.keys.Confirm, "select videos")
+		}
+		b.WriteString("\n" + m.help.ShortHelpView([]key.Binding{confirm, m.keys.Download, m.keys.Back, m.keys.Quit}))
+
+	case screenOutput:
+		b.WriteString(titleStyle.Render(m.info.Title) + "\n")
+		switch {
+		case len(m.items) > 0:
+			b.WriteString(faintStyle.Render(fmt.Sprintf("%d videos, output template", len(m.items))) + "\n\n")
+		case m.format != nil && m.audio != nil:
+			b.WriteString(faintStyle.Render(fmt.Sprintf("Formats %s+%s (%s, %s, %s)", m.format.ID, m.audio.ID,
+				m.format.Quality, formatQuality(m.audio), m.format.Extension)) + "\n\n")
+		case m.format != nil:
+			b.WriteString(faintStyle.Render(fmt.Sprintf("Format %s (%s %s)", m.format.ID, m.format.Quality, m.format.Extension)) + "\n\n")
+		default:
+			b.WriteString(faintStyle.Render("Best available format") + "\n\n")
+		}
+		b.WriteString(m.output.View() + "\n\n")
+		b.WriteString(m.help.ShortHelpView([]key.Binding{withDesc(m.keys.Confirm, "download"), m.keys.Back, m.keys.Quit}))
+
+	default:
+		if m.child != nil {
+			b.WriteString(m.child.View())
+		}
+		if m.err != nil {
+			b.WriteString("\n" + errorStyle.Render(m.err.Error()))
+		}
+	}
+	return b.String()
+}
+
+func (m AppModel) breadcrumb() string {
+	switch m.screen {
+	case screenFetching:
+		return "fetching"
+	case screenInfo:
+		return "info"
+	case screenFormats:
+		return "format"
+	case screenPlaylist:
+		return "videos"
+	case screenOutput:
+		return "output"
+	case screenDownload:
+		return "download"
+	default:
+		return "new download"
+	}
+}
@@ -0,0 +1,477 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/aiomayo/aiodl/internal/events"
+	"github.com/aiomayo/aiodl/internal/tui"
+)
+
+type ItemState int
+
+const (
+	ItemQueued ItemState = iota
+	ItemFetching
+	ItemDownloading
+	ItemMerging
+	ItemDone
+	ItemFailed
+	ItemSkipped
+)
+
+var itemStateNames = [...]string{"queued", "fetching info", "downloading", "merging", "done", "failed", "skipped"}
+
+func (s ItemState) String() string {
+	if int(s) < len(itemStateNames) {
+		return itemStateNames[s]
+	}
+	return "unknown"
+}
+
+func (s ItemState) Finished() bool {
+	return s >= ItemDone
+}
+
+type DashboardDoneMsg struct{}
+
+var skipReasons = map[string]string{
+	"exists":   "file already exists",
+	"filtered": "does not match filters",
+	"archived": "already downloaded",
+}
+
+type DashboardActions struct {
+	Cancel func(index int)
+	Retry  func(index int)
+	Skip   func(index int)
+	Quit   func()
+}
+
+type dashboardTickMsg time.Time
+
+const (
+	dashboardTick   = 500 * time.Millisecond
+	dashboardChrome = 6
+)
+
+type dashboardKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	Cancel   key.Binding
+	Retry    key.Binding
+	Skip     key.Binding
+	Quit     key.Binding
+}
+
+func (k dashboardKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Cancel, k.Retry, k.Skip, k.Quit}
+}
+
+func (k dashboardKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down, k.PageUp, k.PageDown, k.Top, k.Bottom}, {k.Cancel, k.Retry, k.Skip, k.Quit}}
+}
+
+var dashboardKeys = dashboardKeyMap{
+	Up:       key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("up/k", "up")),
+	Down:     key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("down/j", "down")),
+	PageUp:   key.NewBinding(key.WithKeys("pgup", "ctrl+u"), key.WithHelp("pgup", "page up")),
+	PageDown: key.NewBinding(key.WithKeys("pgdown", "ctrl+d"), key.WithHelp("pgdn", "page down")),
+	Top:      key.NewBinding(key.WithKeys("home", "g"), key.WithHelp("g", "top")),
+	Bottom:   key.NewBinding(key.WithKeys("end", "G"), key.WithHelp("G", "bottom")),
+	Cancel:   key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "cancel")),
+	Retry:    key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "retry")),
+	Skip:     key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "skip")),
+	Quit:     key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q", "quit")),
+}
+
+type dashboardItem struct {
+	title      string
+	state      ItemState
+	downloaded int64
+	total      int64
+	err        error
+}
+
+type DashboardModel struct {
+	title   string
+	items   []dashboardItem
+	actions DashboardActions
+
+	counts     [len(itemStateNames)]int
+	downloaded int64
+	total      int64
+
+	lastSample time.Time
+	lastBytes  int64
+	speed      float64
+	startTime  time.Time
+
+	cursor   int
+	offset   int
+	idle     bool
+	quitting bool
+	embedded bool
+
+	bar    progress.Model
+	help   help.Model
+	keys   dashboardKeyMap
+	width  int
+	height int
+}
+
+func NewDashboard(title string, titles []string) DashboardModel {
+	items := make([]dashboardItem, len(titles))
+	for i, t := range titles {
+		items[i] = dashboardItem{title: t}
+	}
+
+	now := time.Now()
+	m := DashboardModel{
+		title:      title,
+		items:      items,
+		bar:        progress.New(append(styles.Progress(), progress.WithWidth(20), progress.WithoutPercentage())...),
+		help:       help.New(),
+		keys:       dashboardKeys,
+		lastSample: now,
+		startTime:  now,
+		width:      80,
+		height:     24,
+	}
+	m.counts[ItemQueued] = len(items)
+	return m
+}
+
+func (m DashboardModel) WithActions(actions DashboardActions) DashboardModel {
+	m.actions = actions
+	return m
+}
+
+func (m DashboardModel) Embedded() DashboardModel {
+	m.embedded = true
+	return m
+}
+
+func (m DashboardModel) Init() tea.Cmd {
+	return m.tickCmd()
+}
+
+func (m DashboardModel) tickCmd() tea.Cmd {
+	return tea.Tick(dashboardTick, func(t time.Time) tea.Msg {
+		return dashboardTickMsg(t)
+	})
+}
+
+func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.help.Width = msg.Width
+		m.scroll()
+
+	case dashboardTickMsg:
+		m.sample(time.Time(msg))
+		return m, m.tickCmd()
+
+	case events.Event:
+		m.handleEvent(msg)
+
+	case DashboardDoneMsg:
+		m.idle = true
+		if m.quitting || m.counts[ItemFailed] == 0 && !m.embedded {
+			return m, finish(m.embedded, BackMsg{})
+		}
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m DashboardModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		if m.idle || m.actions.Quit == nil {
+			return m, finish(m.embedded, BackMsg{})
+		}
+		m.quitting = true
+		m.actions.Quit()
+	case key.Matches(msg, m.keys.Up):
+		m.cursor--
+	case key.Matches(msg, m.keys.Down):
+		m.cursor++
+	case key.Matches(msg, m.keys.PageUp):
+		m.cursor -= m.rows()
+	case key.Matches(msg, m.keys.PageDown):
+		m.cursor += m.rows()
+	case key.Matches(msg, m.keys.Top):
+		m.cursor = 0
+	case key.Matches(msg, m.keys.Bottom):
+		m.cursor = len(m.items) - 1
+	case len(m.items) == 0 || m.quitting:
+	case key.Matches(msg, m.keys.Cancel):
+		if !m.items[m.cursor].state.Finished() && m.actions.Cancel != nil {
+			m.actions.Cancel(m.cursor)
+		}
+	case key.Matches(msg, m.keys.Skip):
+		if !m.items[m.cursor].state.Finished() && m.actions.Skip != nil {
+			m.actions.Skip(m.cursor)
+			if m.items[m.cursor].state == ItemQueued {
+				m.setState(m.cursor, ItemSkipped)
+			}
+		}
+	case key.Matches(msg, m.keys.Retry):
+		if m.items[m.cursor].state == ItemFailed && m.actions.Retry != nil {
+			m.actions.Retry(m.cursor)
+			m.items[m.cursor].err = nil
+			m.setState(m.cursor, ItemQueued)
+			m.setProgress(m.cursor, 0, 0)
+			m.idle = false
+		}
+	}
+	m.scroll()
+	return m, nil
+}
+
+func (m *DashboardModel) handleEvent(e events.Event) {
+	i := e.Item - 1
+	if i < 0 || i >= len(m.items) {
+		return
+	}
+	it := &m.items[i]
+
+	var err error
+	state := it.state
+	switch e.Type {
+	case events.TypeQueued:
+		state = ItemQueued
+	case events.TypeStart, events.TypeInfo, events.TypeFormat:
+		state = ItemFetching
+	case events.TypeMerge:
+		state = ItemMerging
+	case events.TypeProgress:
+		if state != ItemMerging {
+			state = ItemDownloading
+		}
+	case events.TypeDone:
+		state = ItemDone
+	case events.TypeSkipped:
+		state = ItemSkipped
+		if reason := skipReasons[e.Reason]; reason != "" {
+			err = errors.New(reason)
+		}
+	case events.TypeCancelled:
+		state, err = ItemFailed, errors.New("cancelled")
+	case events.TypeError:
+		state, err = ItemFailed, errors.New(e.Error)
+		if e.Stage != "" {
+			err = fmt.Errorf("%s failed: %s", e.Stage, e.Error)
+		}
+	default:
+		return
+	}
+	if it.state.Finished() && !state.Finished() && state != ItemQueued {
+		return
+	}
+
+	switch {
+	case e.Type == events.TypeQueued, e.Type == events.TypeStart:
+		m.setProgress(i, 0, 0)
+	case e.Type == events.TypeProgress:
+		m.setProgress(i, e.Progress.Downloaded, e.Progress.Total)
+	case state == ItemDone:
+		size := max(it.total, it.downloaded, e.Size)
+		m.setProgress(i, size, size)
+	}
+	m.setState(i, state)
+	it.err = err
+}
+
+func (m *DashboardModel) setState(i int, state ItemState) {
+	m.counts[m.items[i].state]--
+	m.counts[state]++
+	m.items[i].state = state
+}
+
+func (m *DashboardModel) setProgress(i int, downloaded, total int64) {
+	it := &m.items[i]
+	m.downloaded += downloaded - it.downloaded
+	m.total += total - it.total
+	it.downloaded, it.total = downloaded, total
+}
+
+func (m *DashboardModel) sample(now time.Time) {
+	elapsed := now.Sub(m.lastSample).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	rate := float64(max(m.downloaded-m.lastBytes, 0)) / elapsed
+	if m.speed == 0 {
+		m.speed = rate
+	} else {
+		m.speed = 0.7*m.speed + 0.3*rate
+	}
+	m.lastSample, m.lastBytes = now, m.downloaded
+}
+
+func (m DashboardModel) rows() int {
+	return max(m.height-dashboardChrome, 1)
+}
+
+func (m *DashboardModel) scroll() {
+	m.cursor = max(min(m.cursor, len(m.items)-1), 0)
+	rows := m.rows()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+	m.offset = max(min(m.offset, len(m.items)-rows), 0)
+}
+
+func (m DashboardModel) View() string {
+	var b strings.Builder
+
+	titleStyle := styles.Title
+	faintStyle := styles.Muted
+
+	b.WriteString(titleStyle.Render(m.title) + "\n")
+	b.WriteString(faintStyle.Render(m.summary()) + "\n\n")
+
+	end := min(m.offset+m.rows(), len(m.items))
+	for i := m.offset; i < end; i++ {
+		b.WriteString(m.renderItem(i) + "\n")
+	}
+	for i := end - m.offset; i < m.rows(); i++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if m.idle {
+		b.WriteString(faintStyle.Render(m.finishedHint()) + "\n")
+	} else {
+		b.WriteString("\n")
+	}
+	b.WriteString(m.help.View(m.keys))
+	return b.String()
+}
+
+func (m DashboardModel) finishedHint() string {
+	switch {
+	case m.counts[ItemFailed] > 0 && m.embedded:
+		return "Finished. Retry failed items with r, or press q to go back."
+	case m.counts[ItemFailed] > 0:
+		return "Finished. Retry failed items with r, or press q to exit."
+	default:
+		return "Finished. Press q to go back."
+	}
+}
+
+func (m DashboardModel) summary() string {
+	done := m.counts[ItemDone] + m.counts[ItemFailed] + m.counts[ItemSkipped]
+	active := m.counts[ItemFetching] + m.counts[ItemDownloading] + m.counts[ItemMerging]
+	parts := []string{
+		fmt.Sprintf("%d/%d finished", done, len(m.items)),
+		fmt.Sprintf("%d active", active),
+	}
+	if m.counts[ItemFailed] > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", m.counts[ItemFailed]))
+	}
+	if m.counts[ItemSkipped] > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", m.counts[ItemSkipped]))
+	}
+	if m.total > 0 {
+		parts = append(parts, fmt.Sprintf("%s / %s", tui.FormatBytes(m.downloaded), tui.FormatBytes(m.total)))
+	} else {
+		parts = append(parts, tui.FormatBytes(m.downloaded))
+	}
+	if active > 0 && m.speed > 0 {
+		parts = append(parts, tui.FormatBytes(int64(m.speed))+"/s")
+		if remaining := float64(m.total-m.downloaded) / m.speed; remaining > 0 && remaining < 86400 {
+			parts = append(parts, "ETA: "+tui.FormatDuration(int(remaining)))
+		}
+	}
+	if m.idle {
+		parts = append(parts, "elapsed "+tui.FormatDurationTime(time.Since(m.startTime)))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (m DashboardModel) renderItem(i int) string {
+	it := m.items[i]
+	faintStyle := styles.Muted
+
+	cursor := "  "
+	if i == m.cursor {
+		cursor = "> "
+	}
+	state := fmt.Sprintf("%-13s", it.state)
+	switch it.state {
+	case ItemDone:
+		state = styles.Success.Render(state)
+	case ItemFailed:
+		state = styles.Error.Render(state)
+	case ItemQueued, ItemSkipped:
+		state = faintStyle.Render(state)
+	}
+
+	var detail string
+	switch {
+	case it.err != nil:
+		detail = faintStyle.Render(it.err.Error())
+	case it.state == ItemDownloading || it.state == ItemMerging:
+		percent := 0.0
+		if it.total > 0 {
+			percent = float64(it.downloaded) / float64(it.total)
+		}
+		detail = m.bar.ViewAs(percent) + fmt.Sprintf(" %5.1f%% %s", percent*100, tui.FormatBytes(it.downloaded))
+	case it.state == ItemDone && it.total > 0:
+		detail = faintStyle.Render(tui.FormatBytes(it.total))
+	}
+
+	prefix := fmt.Sprintf("%s%*d %s ", cursor, len(fmt.Sprint(len(m.items))), i+1, state)
+	titleWidth := m.width - lipgloss.Width(prefix) - lipgloss.Width(detail) - 2
+	if titleWidth < 10 {
+		titleWidth = max(m.width-lipgloss.Width(prefix)-1, 1)
+		detail = ""
+	}
+	title := truncateWidth(it.title, titleWidth)
+	line := prefix + title + strings.Repeat(" ", max(titleWidth-lipgloss.Width(title), 0))
+	if detail != "" {
+		line += "  " + detail
+	}
+	if i == m.cursor {
+		line = styles.Accent.Render(line)
+	}
+	return line
+}
+
+func truncateWidth(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func (m DashboardModel) Quitting() bool {
+	return m.quitting
+}
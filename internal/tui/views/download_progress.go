@@ -1,6 +1,8 @@
 package views
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,8 +12,8 @@ import (
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 
+	"github.com/aiomayo/aiodl/internal/events"
 	"github.com/aiomayo/aiodl/internal/tui"
 )
 
@@ -30,11 +32,14 @@ type DownloadProgressModel struct {
 	downloaded int64
 	total      int64
 	startTime  time.Time
-	lastUpdate time.Time
-	lastBytes  int64
-	speed      float64
+	speed      int64
+	eta        int
+	step       string
 	state      DownloadState
 	err        error
+	cancel     context.CancelFunc
+	finished   bool
+	embedded   bool
 
 	progress progress.Model
 	spinner  spinner.Model
@@ -63,10 +68,11 @@ var defaultProgressKeys = progressKeyMap{
 type tickMsg time.Time
 
 func NewDownloadProgress(filename string) DownloadProgressModel {
-	p := progress.New(progress.WithDefaultGradient(), progress.WithWidth(50))
+	p := progress.New(append(styles.Progress(), progress.WithWidth(50))...)
 
 	s := spinner.New()
 	s.Spinner = spinner.Dot
+	s.Style = styles.Accent
 
 	return DownloadProgressModel{
 		filename:  filename,
@@ -79,6 +85,16 @@ func NewDownloadProgress(filename string) DownloadProgressModel {
 	}
 }
 
+func (m DownloadProgressModel) WithCancel(cancel context.CancelFunc) DownloadProgressModel {
+	m.cancel = cancel
+	return m
+}
+
+func (m DownloadProgressModel) Embedded() DownloadProgressModel {
+	m.embedded = true
+	return m
+}
+
 func (m DownloadProgressModel) Init() tea.Cmd {
 	return tea.Batch(m.spinner.Tick, m.tickCmd())
 }
@@ -101,11 +117,14 @@ func (m DownloadProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tickMsg:
-		if m.state == StateComplete || m.state == StateError || m.state == StateCancelled {
+		if m.finished {
 			return m, nil
 		}
 		return m, m.tickCmd()
 
+	case events.Event:
+		return m.handleEvent(msg)
+
 	case spinner.TickMsg:
 		if m.state == StateInitializing {
 			var cmd tea.Cmd
@@ -121,30 +140,73 @@ func (m DownloadProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		if key.Matches(msg, m.keys.Quit) {
+			if m.finished || m.cancel == nil {
+				if !m.finished {
+					m.state = StateCancelled
+				}
+				return m, finish(m.embedded, BackMsg{})
+			}
 			m.state = StateCancelled
-			return m, tea.Quit
+			m.cancel()
 		}
 	}
 
 	return m, nil
 }
 
-func (m *DownloadProgressModel) updateSpeed() {
-	now := time.Now()
-	elapsed := now.Sub(m.lastUpdate).Seconds()
-	if elapsed > 0.5 {
-		bytesTransferred := m.downloaded - m.lastBytes
-		m.speed = float64(bytesTransferred) / elapsed
-		m.lastUpdate = now
-		m.lastBytes = m.downloaded
+func (m DownloadProgressModel) handleEvent(e events.Event) (tea.Model, tea.Cmd) {
+	switch e.Type {
+	case events.TypeProgress:
+		if m.state != StateCancelled {
+			m.SetProgress(e.Progress.Downloaded, e.Progress.Total)
+			m.speed, m.eta = e.Progress.Speed, e.Progress.ETA
+		}
+		return m, nil
+	case events.TypeMerge:
+		m.step = "merging"
+		return m, nil
+	case events.TypePostProcess:
+		m.step = e.Step
+		return m, nil
+	case events.TypeRetry:
+		m.step = fmt.Sprintf("retry %d", e.Attempt)
+		return m, nil
 	}
+	if !e.Finished() {
+		return m, nil
+	}
+
+	if e.Path != "" {
+		m.filename = e.Path
+	}
+	switch {
+	case e.Type == events.TypeDone:
+		m.total = max(m.total, e.Size)
+		m.SetComplete()
+	case e.Type == events.TypeSkipped:
+		reason := skipReasons[e.Reason]
+		if reason == "" {
+			reason = "skipped"
+		}
+		m.SetError(errors.New(reason))
+	case m.state == StateCancelled || e.Type == events.TypeCancelled:
+		m.state = StateCancelled
+	default:
+		m.SetError(errors.New(e.Error))
+	}
+	m.finished = true
+	if m.embedded {
+		setDesc(&m.keys.Quit, "back")
+		return m, nil
+	}
+	return m, tea.Quit
 }
 
 func (m DownloadProgressModel) View() string {
 	var b strings.Builder
 
-	titleStyle := lipgloss.NewStyle().Bold(true)
-	faintStyle := lipgloss.NewStyle().Faint(true)
+	titleStyle := styles.Title
+	faintStyle := styles.Muted
 
 	b.WriteString(titleStyle.Render(m.filename) + "\n\n")
 
@@ -166,13 +228,13 @@ func (m DownloadProgressModel) View() string {
 			parts = append(parts, tui.FormatBytes(m.downloaded))
 		}
 		if m.speed > 0 {
-			parts = append(parts, tui.FormatBytes(int64(m.speed))+"/s")
+			parts = append(parts, tui.FormatBytes(m.speed)+"/s")
 		}
-		if m.speed > 0 && m.total > 0 {
-			remaining := float64(m.total-m.downloaded) / m.speed
-			if remaining > 0 && remaining < 86400 {
-				parts = append(parts, "ETA: "+tui.FormatDuration(int(remaining)))
-			}
+		if m.eta > 0 && m.eta < 86400 {
+			parts = append(parts, "ETA: "+tui.FormatDuration(m.eta))
+		}
+		if m.step != "" {
+			parts = append(parts, m.step)
 		}
 		b.WriteString(faintStyle.Render(strings.Join(parts, " | ")))
 		b.WriteString(fmt.Sprintf("  %.1f%%\n", percent*100))
@@ -194,7 +256,7 @@ func (m DownloadProgressModel) View() string {
 		if m.err != nil {
 			errMsg = fmt.Sprintf("Failed: %v", m.err)
 		}
-		b.WriteString(errMsg + "\n")
+		b.WriteString(styles.Error.Render(errMsg) + "\n")
 
 	case StateCancelled:
 		percent := 0.0
@@ -220,11 +282,11 @@ func (m *DownloadProgressModel) SetProgress(downloaded, total int64) {
 	if m.state == StateInitializing {
 		m.state = StateDownloading
 	}
-	m.updateSpeed()
 }
 
 func (m *DownloadProgressModel) SetComplete() {
 	m.state = StateComplete
+	m.total = max(m.total, m.downloaded)
 	m.downloaded = m.total
 }
 
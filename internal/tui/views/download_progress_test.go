@@ -0,0 +1,183 @@
+package views
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aiomayo/aiodl/internal/events"
+)
+
+func progressEvent(downloaded, total int64) events.Event {
+	e := events.New(events.TypeProgress)
+	e.Progress = &events.Progress{Downloaded: downloaded, Total: total, Speed: 1024, ETA: 5}
+	return e
+}
+
+func finishedEvent(t events.Type, errMsg string) events.Event {
+	e := events.New(t)
+	e.Path = "video.mp4"
+	e.Size = 4096
+	e.Error = errMsg
+	return e
+}
+
+func send(t *testing.T, m DownloadProgressModel, msgs ...tea.Msg) (DownloadProgressModel, tea.Cmd) {
+	t.Helper()
+	var cmd tea.Cmd
+	for _, msg := range msgs {
+		var next tea.Model
+		next, cmd = m.Update(msg)
+		m = next.(DownloadProgressModel)
+	}
+	return m, cmd
+}
+
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func TestDownloadProgressUpdates(t *testing.T) {
+	m, _ := send(t, NewDownloadProgress("video"), progressEvent(1024, 4096), progressEvent(2048, 4096))
+
+	if m.state != StateDownloading {
+		t.Fatalf("state = %v, want downloading", m.state)
+	}
+	if m.downloaded != 2048 || m.total != 4096 {
+		t.Fatalf("downloaded/total = %d/%d, want 2048/4096", m.downloaded, m.total)
+	}
+	view := m.View()
+	for _, want := range []string{"video", "2.0 KiB / 4.0 KiB", "1.0 KiB/s", "50.0%"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestDownloadProgressFinished(t *testing.T) {
+	tests := []struct {
+		name  string
+		event events.Event
+		state DownloadState
+		view  string
+	}{
+		{"done", finishedEvent(events.TypeDone, ""), StateComplete, "Complete!"},
+		{"error", finishedEvent(events.TypeError, "HTTP 403: forbidden"), StateError, "Failed: HTTP 403: forbidden"},
+		{"cancelled", finishedEvent(events.TypeCancelled, context.Canceled.Error()), StateCancelled, "Cancelled. Downloaded 2.0 KiB of 4.0 KiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, cmd := send(t, NewDownloadProgress("video"), progressEvent(2048, 4096), tt.event)
+
+			if m.state != tt.state {
+				t.Fatalf("state = %v, want %v", m.state, tt.state)
+			}
+			if !m.finished {
+				t.Fatal("model not marked finished")
+			}
+			if m.filename != "video.mp4" {
+				t.Errorf("filename = %q, want video.mp4", m.filename)
+			}
+			if !isQuit(cmd) {
+				t.Error("expected quit command")
+			}
+			if view := m.View(); !strings.Contains(view, tt.view) {
+				t.Errorf("view missing %q:\n%s", tt.view, view)
+			}
+		})
+	}
+}
+
+func TestDownloadProgressCancelKey(t *testing.T) {
+	cancelled := 0
+	m := NewDownloadProgress("video").WithCancel(func() { cancelled++ })
+
+	m, cmd := send(t, m, progressEvent(1024, 4096), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if cancelled != 1 {
+		t.Fatalf("cancel called %d times, want 1", cancelled)
+	}
+	if m.state != StateCancelled {
+		t.Fatalf("state = %v, want cancelled", m.state)
+	}
+	if cmd != nil {
+		t.Fatal("expected model to wait for the cancelled event before quitting")
+	}
+
+	m, _ = send(t, m, progressEvent(3072, 4096))
+	if m.downloaded != 1024 {
+		t.Errorf("progress after cancel applied: downloaded = %d", m.downloaded)
+	}
+
+	m, cmd = send(t, m, finishedEvent(events.TypeError, context.Canceled.Error()))
+	if m.state != StateCancelled {
+		t.Fatalf("state = %v, want cancelled", m.state)
+	}
+	if !isQuit(cmd) {
+		t.Error("expected quit command")
+	}
+	if view := m.View(); !strings.Contains(view, "Cancelled.") {
+		t.Errorf("view missing cancelled message:\n%s", view)
+	}
+
+	_, cmd = send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if cancelled != 1 {
+		t.Errorf("cancel called again after finish")
+	}
+	if !isQuit(cmd) {
+		t.Error("expected quit command after finish")
+	}
+}
+
+func TestDownloadProgressCancelKeyWithoutCancel(t *testing.T) {
+	m, cmd := send(t, NewDownloadProgress("video"), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if m.state != StateCancelled {
+		t.Fatalf("state = %v, want cancelled", m.state)
+	}
+	if !isQuit(cmd) {
+		t.Error("expected quit command")
+	}
+}
+
+func TestDownloadProgressProgram(t *testing.T) {
+	var out bytes.Buffer
+	p := tea.NewProgram(NewDownloadProgress("video"),
+		tea.WithInput(nil), tea.WithOutput(&out), tea.WithoutSignalHandler())
+
+	go func() {
+		for i := int64(1); i <= 4; i++ {
+			p.Send(progressEvent(i*1024, 4096))
+		}
+		p.Send(finishedEvent(events.TypeDone, ""))
+	}()
+
+	done := make(chan tea.Model, 1)
+	go func() {
+		final, err := p.Run()
+		if err != nil {
+			t.Error(err)
+		}
+		done <- final
+	}()
+
+	select {
+	case final := <-done:
+		m := final.(DownloadProgressModel)
+		if !m.IsComplete() {
+			t.Fatalf("state = %v, want complete", m.state)
+		}
+		if m.downloaded != 4096 {
+			t.Errorf("downloaded = %d, want 4096", m.downloaded)
+		}
+	case <-time.After(5 * time.Second):
+		p.Kill()
+		t.Fatal("program did not quit after done event")
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 
 	"github.com/aiomayo/aiodl/internal/adapter"
 	"github.com/aiomayo/aiodl/internal/tui"
@@ -23,11 +22,12 @@ type formatKeyMap struct {
 	Video  key.Binding
 	Audio  key.Binding
 	All    key.Binding
+	Skip   key.Binding
 	Quit   key.Binding
 }
 
 func (k formatKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Select, k.Sort, k.Video, k.Audio, k.All, k.Quit}
+	return []key.Binding{k.Select, k.Sort, k.Video, k.Audio, k.All, k.Skip, k.Quit}
 }
 
 func (k formatKeyMap) FullHelp() [][]key.Binding {
@@ -42,6 +42,7 @@ var defaultFormatKeys = formatKeyMap{
 	Video:  key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "video")),
 	Audio:  key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "audio")),
 	All:    key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "all")),
+	Skip:   key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "no audio"), key.WithDisabled()),
 	Quit:   key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q", "quit")),
 }
 
@@ -71,7 +72,11 @@ type FormatSelectorModel struct {
 	sortMode   SortMode
 	filterMode FilterMode
 	selected   *adapter.Format
+	video      *adapter.Format
+	audio      *adapter.Format
+	duration   int
 	cancelled  bool
+	embedded   bool
 	width      int
 	height     int
 }
@@ -99,20 +104,37 @@ func NewFormatSelector(title string, formats []adapter.Format) FormatSelectorMod
 		table.WithColumns(columns),
 		table.WithRows(rows),
 		table.WithFocused(true),
+		table.WithKeyMap(formatTableKeys),
 		table.WithHeight(15),
 	)
-	t.SetStyles(table.DefaultStyles())
+	ts := table.DefaultStyles()
+	ts.Selected = styles.Selected
+	t.SetStyles(ts)
 	m.table = t
 	m.applyFiltersAndSort()
 
 	return m
 }
 
+func (m FormatSelectorModel) Embedded() FormatSelectorModel {
+	m.embedded = true
+	setDesc(&m.keys.Quit, "back")
+	return m
+}
+
+func (m FormatSelectorModel) WithDuration(seconds int) FormatSelectorModel {
+	m.duration = seconds
+	return m
+}
+
 func (m FormatSelectorModel) buildRows(formats []adapter.Format) []table.Row {
 	var rows []table.Row
 	for _, f := range formats {
 		ftype := "audio"
-		if f.IsVideo() {
+		switch {
+		case f.IsVideo() && f.HasAudio():
+			ftype = "muxed"
+		case f.IsVideo():
 			ftype = "video"
 		}
 		rows = append(rows, table.Row{
@@ -133,22 +155,34 @@ func (m FormatSelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.help.Width = msg.Width
-		m.table.SetHeight(msg.Height - 10)
+		m.table.SetHeight(msg.Height - 11)
 
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keys.Quit):
+			if m.video != nil {
+				m.setVideo(nil)
+				return m, nil
+			}
 			m.cancelled = true
-			return m, tea.Quit
+			return m, finish(m.embedded, BackMsg{})
+
+		case key.Matches(msg, m.keys.Skip):
+			m.selected = m.video
+			return m, finish(m.embedded, FormatSelectedMsg{Format: m.selected})
 
 		case key.Matches(msg, m.keys.Select):
-			if len(m.filtered) > 0 {
-				idx := m.table.Cursor()
-				if idx < len(m.filtered) {
-					m.selected = &m.filtered[idx]
-				}
+			f := m.current()
+			switch {
+			case m.video != nil:
+				m.selected, m.audio = m.video, f
+			case f != nil && f.IsVideo() && !f.HasAudio() && len(m.audioFor(f)) > 0:
+				m.setVideo(f)
+				return m, nil
+			default:
+				m.selected = f
 			}
-			return m, tea.Quit
+			return m, finish(m.embedded, FormatSelectedMsg{Format: m.selected, Audio: m.audio})
 
 		case key.Matches(msg, m.keys.Sort):
 			m.sortMode = (m.sortMode + 1) % 3
@@ -173,10 +207,53 @@ func (m FormatSelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m FormatSelectorModel) current() *adapter.Format {
+	if idx := m.table.Cursor(); idx >= 0 && idx < len(m.filtered) {
+		f := m.filtered[idx]
+		return &f
+	}
+	return nil
+}
+
+func (m FormatSelectorModel) audioFor(video *adapter.Format) []adapter.Format {
+	var audio []adapter.Format
+	for _, f := range m.formats {
+		if !f.IsVideo() && f.Extension == video.Extension {
+			audio = append(audio, f)
+		}
+	}
+	return audio
+}
+
+func (m *FormatSelectorModel) setVideo(video *adapter.Format) {
+	m.video = video
+	m.keys.Skip.SetEnabled(video != nil)
+	m.keys.Video.SetEnabled(video == nil)
+	m.keys.Audio.SetEnabled(video == nil)
+	m.keys.All.SetEnabled(video == nil)
+	setDesc(&m.keys.Select, "select")
+	setDesc(&m.keys.Quit, "quit")
+	if video != nil {
+		setDesc(&m.keys.Select, "add audio")
+	}
+	if video != nil || m.embedded {
+		setDesc(&m.keys.Quit, "back")
+	}
+	m.applyFiltersAndSort()
+	m.table.SetCursor(0)
+}
+
 func (m *FormatSelectorModel) applyFiltersAndSort() {
+	formats := m.formats
+	filterMode := m.filterMode
+	if m.video != nil {
+		formats = m.audioFor(m.video)
+		filterMode = FilterAll
+	}
+
 	var filtered []adapter.Format
-	for _, f := range m.formats {
-		switch m.filterMode {
+	for _, f := range formats {
+		switch filterMode {
 		case FilterVideoOnly:
 			if f.IsVideo() {
 				filtered = append(filtered, f)
@@ -215,14 +292,16 @@ func (m *FormatSelectorModel) applyFiltersAndSort() {
 func (m FormatSelectorModel) View() string {
 	var b strings.Builder
 
-	titleStyle := lipgloss.NewStyle().Bold(true).MarginBottom(1)
+	titleStyle := styles.Title.MarginBottom(1)
 	b.WriteString(titleStyle.Render(m.title) + "\n\n")
 
 	statusParts := []string{fmt.Sprintf("Formats: %d", len(m.filtered))}
-	switch m.filterMode {
-	case FilterVideoOnly:
+	switch {
+	case m.video != nil:
+		statusParts[0] = fmt.Sprintf("Audio tracks: %d", len(m.filtered))
+	case m.filterMode == FilterVideoOnly:
 		statusParts = append(statusParts, "Filter: Video")
-	case FilterAudioOnly:
+	case m.filterMode == FilterAudioOnly:
 		statusParts = append(statusParts, "Filter: Audio")
 	}
 	switch m.sortMode {
@@ -234,18 +313,80 @@ func (m FormatSelectorModel) View() string {
 		statusParts = append(statusParts, "Sort: Bitrate")
 	}
 
-	statusStyle := lipgloss.NewStyle().Faint(true)
-	b.WriteString(statusStyle.Render(strings.Join(statusParts, " | ")) + "\n\n")
+	statusStyle := styles.Muted
+	b.WriteString(statusStyle.Render(strings.Join(statusParts, " | ")) + "\n")
+	b.WriteString(m.pairView() + "\n\n")
 	b.WriteString(m.table.View() + "\n\n")
 	b.WriteString(m.help.View(m.keys))
 
 	return b.String()
 }
 
+func (m FormatSelectorModel) pairView() string {
+	f := m.current()
+	switch {
+	case m.video != nil && f != nil:
+		sel := adapter.Selection{Formats: []adapter.Format{*m.video, *f}}
+		return fmt.Sprintf("Video %s (%s) + audio %s (%s) → %s, %s",
+			m.video.ID, m.video.Quality, f.ID, formatQuality(f), sel.Extension(), m.estimateSize(sel.Formats))
+	case m.video != nil:
+		return fmt.Sprintf("Video %s (%s), no compatible audio tracks", m.video.ID, m.video.Quality)
+	case f != nil && f.IsVideo() && !f.HasAudio():
+		if len(m.audioFor(f)) == 0 {
+			return "Video only, no compatible audio tracks to merge"
+		}
+		return "Video only, press enter to pick an audio track to merge"
+	}
+	return ""
+}
+
+func (m FormatSelectorModel) estimateSize(formats []adapter.Format) string {
+	size, approx, ok := estimateSize(formats, m.duration)
+	if !ok {
+		return "size unknown"
+	}
+	return formatEstimate(size, approx)
+}
+
+func estimateSize(formats []adapter.Format, duration int) (int64, bool, bool) {
+	var total int64
+	approx := false
+	for _, f := range formats {
+		switch {
+		case f.FileSize > 0:
+			total += f.FileSize
+		case f.Bitrate > 0 && duration > 0:
+			total += int64(f.Bitrate) / 8 * int64(duration)
+			approx = true
+		default:
+			return 0, false, false
+		}
+	}
+	return total, approx, true
+}
+
+func formatEstimate(size int64, approx bool) string {
+	if approx {
+		return "~" + tui.FormatSize(size)
+	}
+	return tui.FormatSize(size)
+}
+
+func formatQuality(f *adapter.Format) string {
+	if f.Quality != "" {
+		return f.Quality
+	}
+	return tui.FormatBitrate(f.Bitrate)
+}
+
 func (m FormatSelectorModel) Selected() *adapter.Format {
 	return m.selected
 }
 
+func (m FormatSelectorModel) SelectedAudio() *adapter.Format {
+	return m.audio
+}
+
 func (m FormatSelectorModel) Cancelled() bool {
 	return m.cancelled
 }
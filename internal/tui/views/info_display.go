@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/charmbracelet/lipgloss"
-
 	"github.com/aiomayo/aiodl/internal/adapter"
 	"github.com/aiomayo/aiodl/internal/tui"
 )
@@ -13,9 +11,9 @@ import (
 func RenderInfo(info *adapter.MediaInfo) string {
 	var b strings.Builder
 
-	titleStyle := lipgloss.NewStyle().Bold(true)
-	labelStyle := lipgloss.NewStyle().Faint(true).Width(12)
-	faintStyle := lipgloss.NewStyle().Faint(true)
+	titleStyle := styles.Title
+	labelStyle := styles.Muted.Width(12)
+	faintStyle := styles.Muted
 
 	addRow := func(label, value string) {
 		b.WriteString(labelStyle.Render(label+":") + " " + value + "\n")
@@ -86,8 +84,8 @@ func findBestFormats(formats []adapter.Format) (*adapter.Format, *adapter.Format
 func RenderFormats(title string, formats []adapter.Format) string {
 	var b strings.Builder
 
-	titleStyle := lipgloss.NewStyle().Bold(true)
-	headerStyle := lipgloss.NewStyle().Faint(true)
+	titleStyle := styles.Title
+	headerStyle := styles.Muted
 
 	b.WriteString(titleStyle.Render(title) + "\n\n")
 	b.WriteString(headerStyle.Render(
@@ -111,7 +109,7 @@ func RenderFormats(title string, formats []adapter.Format) string {
 func RenderPlaylistItems(items []adapter.MediaInfo, maxItems int) string {
 	var b strings.Builder
 
-	faintStyle := lipgloss.NewStyle().Faint(true)
+	faintStyle := styles.Muted
 
 	shown := items
 	if maxItems > 0 && len(items) > maxItems {
@@ -0,0 +1,282 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+var (
+	formatTableKeys  = table.DefaultKeyMap()
+	playlistListKeys = list.DefaultKeyMap()
+)
+
+var namedKeys = map[string]bool{
+	"enter": true, "esc": true, "tab": true, "backspace": true, "delete": true, "insert": true,
+	"up": true, "down": true, "left": true, "right": true, "home": true, "end": true,
+	"pgup": true, "pgdown": true,
+}
+
+type keyAction struct {
+	name     string
+	bindings []*key.Binding
+}
+
+type keyView struct {
+	name    string
+	actions []keyAction
+}
+
+func action(name string, bindings ...*key.Binding) keyAction {
+	return keyAction{name: name, bindings: bindings}
+}
+
+func keyViews() []keyView {
+	return []keyView{
+		{name: "app", actions: []keyAction{
+			action("confirm", &appKeys.Confirm),
+			action("download", &appKeys.Download),
+			action("back", &appKeys.Back),
+			action("quit", &appKeys.Quit),
+		}},
+		{name: "dashboard", actions: []keyAction{
+			action("up", &dashboardKeys.Up),
+			action("down", &dashboardKeys.Down),
+			action("page_up", &dashboardKeys.PageUp),
+			action("page_down", &dashboardKeys.PageDown),
+			action("top", &dashboardKeys.Top),
+			action("bottom", &dashboardKeys.Bottom),
+			action("cancel", &dashboardKeys.Cancel),
+			action("retry", &dashboardKeys.Retry),
+			action("skip", &dashboardKeys.Skip),
+			action("quit", &dashboardKeys.Quit),
+		}},
+		{name: "format", actions: []keyAction{
+			action("up", &defaultFormatKeys.Up, &formatTableKeys.LineUp),
+			action("down", &defaultFormatKeys.Down, &formatTableKeys.LineDown),
+			action("page_up", &formatTableKeys.PageUp),
+			action("page_down", &formatTableKeys.PageDown),
+			action("half_page_up", &formatTableKeys.HalfPageUp),
+			action("half_page_down", &formatTableKeys.HalfPageDown),
+			action("top", &formatTableKeys.GotoTop),
+			action("bottom", &formatTableKeys.GotoBottom),
+			action("select", &defaultFormatKeys.Select),
+			action("sort", &defaultFormatKeys.Sort),
+			action("video", &defaultFormatKeys.Video),
+			action("audio", &defaultFormatKeys.Audio),
+			action("all", &defaultFormatKeys.All),
+			action("skip", &defaultFormatKeys.Skip),
+			action("quit", &defaultFormatKeys.Quit),
+		}},
+		{name: "playlist", actions: []keyAction{
+			action("up", &playlistListKeys.CursorUp),
+			action("down", &playlistListKeys.CursorDown),
+			action("page_up", &playlistListKeys.PrevPage),
+			action("page_down", &playlistListKeys.NextPage),
+			action("top", &playlistListKeys.GoToStart),
+			action("bottom", &playlistListKeys.GoToEnd),
+			action("filter", &playlistListKeys.Filter),
+			action("help", &playlistListKeys.ShowFullHelp, &playlistListKeys.CloseFullHelp),
+			action("toggle", &playlistKeys.Toggle),
+			action("range_up", &playlistKeys.RangeUp),
+			action("range_down", &playlistKeys.RangeDown),
+			action("range", &playlistKeys.Range),
+			action("all", &playlistKeys.All),
+			action("none", &playlistKeys.None),
+			action("invert", &playlistKeys.Invert),
+			action("shorter", &playlistKeys.Shorter),
+			action("longer", &playlistKeys.Longer),
+			action("sort", &playlistKeys.Sort),
+			action("reverse", &playlistKeys.Reverse),
+			action("confirm", &playlistKeys.Confirm),
+			action("quit", &playlistKeys.Quit),
+		}},
+		{name: "progress", actions: []keyAction{
+			action("quit", &defaultProgressKeys.Quit),
+		}},
+	}
+}
+
+func SetKeyBindings(bindings map[string]map[string][]string) error {
+	views := keyViews()
+	byName := make(map[string]keyView, len(views))
+	keys := make(map[string][]string)
+	for _, v := range views {
+		byName[v.name] = v
+		for _, a := range v.actions {
+			keys[v.name+"."+a.name] = a.bindings[0].Keys()
+		}
+	}
+
+	overrides := make(map[string][]string)
+	for _, viewName := range sortedKeys(bindings) {
+		v, ok := byName[viewName]
+		if !ok {
+			names := make([]string, len(views))
+			for i, v := range views {
+				names[i] = v.name
+			}
+			return fmt.Errorf("unknown view %q (want %s)", viewName, strings.Join(names, ", "))
+		}
+		for _, actionName := range sortedKeys(bindings[viewName]) {
+			id := viewName + "." + actionName
+			if _, ok := keys[id]; !ok {
+				names := make([]string, len(v.actions))
+				for i, a := range v.actions {
+					names[i] = a.name
+				}
+				return fmt.Errorf("unknown action %q for %s (want %s)", actionName, viewName, strings.Join(names, ", "))
+			}
+
+			var normalized []string
+			for _, k := range bindings[viewName][actionName] {
+				n, err := normalizeKey(k)
+				if err != nil {
+					return fmt.Errorf("%s: %w", id, err)
+				}
+				if !slices.Contains(normalized, n) {
+					normalized = append(normalized, n)
+				}
+			}
+			if len(normalized) == 0 {
+				return fmt.Errorf("%s: no keys given", id)
+			}
+			keys[id] = normalized
+			overrides[id] = normalized
+		}
+	}
+
+	if err := checkConflicts(views, keys); err != nil {
+		return err
+	}
+
+	for _, v := range views {
+		for _, a := range v.actions {
+			ks, ok := overrides[v.name+"."+a.name]
+			if !ok {
+				continue
+			}
+			for _, b := range a.bindings {
+				b.SetKeys(ks...)
+				b.SetHelp(helpKeys(ks), b.Help().Desc)
+			}
+		}
+	}
+	return nil
+}
+
+func checkConflicts(views []keyView, keys map[string][]string) error {
+	for _, v := range views {
+		owners := make(map[string]string)
+		for _, a := range v.actions {
+			id := v.name + "." + a.name
+			for _, k := range keys[id] {
+				if other, ok := owners[k]; ok {
+					return fmt.Errorf("key %q is bound to both %s and %s", displayKey(k), other, id)
+				}
+				owners[k] = id
+			}
+		}
+	}
+
+	for _, k := range keys["app.quit"] {
+		if utf8.RuneCountInString(k) == 1 {
+			return fmt.Errorf("key %q cannot be bound to app.quit: it applies on every screen and would block text input", displayKey(k))
+		}
+		for _, v := range views {
+			if v.name == "app" {
+				continue
+			}
+			for _, a := range v.actions {
+				if a.name != "quit" && slices.Contains(keys[v.name+"."+a.name], k) {
+					return fmt.Errorf("key %q is bound to both app.quit and %s.%s", displayKey(k), v.name, a.name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func normalizeKey(k string) (string, error) {
+	if k == " " || strings.EqualFold(k, "space") {
+		return " ", nil
+	}
+	k = strings.TrimSpace(k)
+	if utf8.RuneCountInString(k) == 1 {
+		return k, nil
+	}
+
+	name := strings.ToLower(k)
+	base := name
+	modified := false
+	for {
+		rest, ok := cutModifier(base)
+		if !ok {
+			break
+		}
+		base, modified = rest, true
+	}
+	switch {
+	case namedKeys[base], isFunctionKey(base):
+		return name, nil
+	case modified && utf8.RuneCountInString(base) == 1:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown key %q", k)
+}
+
+func cutModifier(k string) (string, bool) {
+	for _, mod := range []string{"ctrl+", "alt+", "shift+"} {
+		if rest, ok := strings.CutPrefix(k, mod); ok && rest != "" {
+			return rest, true
+		}
+	}
+	return k, false
+}
+
+func isFunctionKey(k string) bool {
+	var n int
+	if _, err := fmt.Sscanf(k, "f%d", &n); err != nil {
+		return false
+	}
+	return n >= 1 && n <= 20 && k == fmt.Sprintf("f%d", n)
+}
+
+func helpKeys(keys []string) string {
+	display := make([]string, len(keys))
+	for i, k := range keys {
+		display[i] = displayKey(k)
+	}
+	return strings.Join(display, "/")
+}
+
+func displayKey(k string) string {
+	if k == " " {
+		return "space"
+	}
+	return k
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func setDesc(b *key.Binding, desc string) {
+	b.SetHelp(b.Help().Key, desc)
+}
+
+func withDesc(b key.Binding, desc string) key.Binding {
+	setDesc(&b, desc)
+	return b
+}
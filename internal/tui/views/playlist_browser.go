@@ -1,21 +1,30 @@
 package views
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/aiomayo/aiodl/internal/adapter"
 	"github.com/aiomayo/aiodl/internal/tui"
+	"github.com/aiomayo/aiodl/match"
 )
 
+const detailTimeout = 30 * time.Second
+
 type PlaylistItem struct {
-	info adapter.MediaInfo
+	info  adapter.MediaInfo
+	index int
 }
 
 func (i PlaylistItem) Title() string       { return i.info.Title }
@@ -63,40 +72,127 @@ func (d playlistDelegate) Render(w io.Writer, m list.Model, index int, item list
 		duration = fmt.Sprintf(" (%s)", tui.FormatDuration(i.info.Duration))
 	}
 
-	idx := fmt.Sprintf("%3d. ", index+1)
+	idx := fmt.Sprintf("%3d. ", i.index)
 
 	line := fmt.Sprintf("%s%s%s %s%s", cursor, idx, checkbox, title, duration)
 	if isCursor {
-		line = lipgloss.NewStyle().Bold(true).Render(line)
+		line = styles.Accent.Render(line)
 	}
 
 	_, _ = fmt.Fprint(w, line)
 }
 
+type PlaylistSort int
+
+const (
+	PlaylistSortIndex PlaylistSort = iota
+	PlaylistSortTitle
+	PlaylistSortDuration
+	PlaylistSortDate
+)
+
+func (s PlaylistSort) String() string {
+	switch s {
+	case PlaylistSortTitle:
+		return "Title"
+	case PlaylistSortDuration:
+		return "Duration"
+	case PlaylistSortDate:
+		return "Date"
+	default:
+		return "Index"
+	}
+}
+
 type playlistKeyMap struct {
-	Toggle  key.Binding
-	All     key.Binding
-	None    key.Binding
-	Invert  key.Binding
-	Confirm key.Binding
-	Quit    key.Binding
+	Toggle    key.Binding
+	RangeUp   key.Binding
+	RangeDown key.Binding
+	Range     key.Binding
+	All       key.Binding
+	None      key.Binding
+	Invert    key.Binding
+	Shorter   key.Binding
+	Longer    key.Binding
+	Sort      key.Binding
+	Reverse   key.Binding
+	Confirm   key.Binding
+	Quit      key.Binding
 }
 
 func (k playlistKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Toggle, k.All, k.None, k.Confirm, k.Quit}
+	return []key.Binding{k.Toggle, k.All, k.None, k.Sort, k.Confirm, k.Quit}
 }
 
 func (k playlistKeyMap) FullHelp() [][]key.Binding {
-	return [][]key.Binding{{k.Toggle, k.All, k.None, k.Invert}, {k.Confirm, k.Quit}}
+	return [][]key.Binding{
+		{k.Toggle, k.RangeUp, k.RangeDown, k.Range},
+		{k.All, k.None, k.Invert, k.Shorter, k.Longer},
+		{k.Sort, k.Reverse, k.Confirm, k.Quit},
+	}
 }
 
 var playlistKeys = playlistKeyMap{
-	Toggle:  key.NewBinding(key.WithKeys(" ", "x"), key.WithHelp("space/x", "toggle")),
-	All:     key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "all")),
-	None:    key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "none")),
-	Invert:  key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "invert")),
-	Confirm: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
-	Quit:    key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q", "quit")),
+	Toggle:    key.NewBinding(key.WithKeys(" ", "x"), key.WithHelp("space/x", "toggle")),
+	RangeUp:   key.NewBinding(key.WithKeys("shift+up", "K"), key.WithHelp("shift+up/K", "select up")),
+	RangeDown: key.NewBinding(key.WithKeys("shift+down", "J"), key.WithHelp("shift+down/J", "select down")),
+	Range:     key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "select range")),
+	All:       key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "all")),
+	None:      key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "none")),
+	Invert:    key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "invert")),
+	Shorter:   key.NewBinding(key.WithKeys("<"), key.WithHelp("<", "select shorter than")),
+	Longer:    key.NewBinding(key.WithKeys(">"), key.WithHelp(">", "select longer than")),
+	Sort:      key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sort")),
+	Reverse:   key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "reverse")),
+	Confirm:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+	Quit:      key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q", "quit")),
+}
+
+type playlistDetailMsg struct {
+	id   string
+	info *adapter.MediaInfo
+	err  error
+}
+
+type sizeEstimate struct {
+	size   int64
+	approx bool
+}
+
+type playlistDetails struct {
+	fetch   func(ctx context.Context, url string) (*adapter.MediaInfo, error)
+	info    map[string]*adapter.MediaInfo
+	sizes   map[string]sizeEstimate
+	failed  map[string]bool
+	pending string
+}
+
+func (d *playlistDetails) get(id string) *adapter.MediaInfo {
+	if d == nil {
+		return nil
+	}
+	return d.info[id]
+}
+
+func (d *playlistDetails) missing(id string) bool {
+	return d.info[id] == nil && !d.failed[id] && d.pending != id
+}
+
+func (d *playlistDetails) update(msg playlistDetailMsg) {
+	d.pending = ""
+	if msg.err != nil || msg.info == nil {
+		d.failed[msg.id] = true
+		return
+	}
+	d.info[msg.id] = msg.info
+
+	sel, err := adapter.ChooseFormats(msg.info.Formats, adapter.DownloadOptions{}, nil)
+	if err != nil {
+		return
+	}
+	if size, approx, ok := estimateSize(sel.Formats, msg.info.Duration); ok {
+		d.sizes[msg.id] = sizeEstimate{size: size, approx: approx}
+	}
 }
 
 type PlaylistBrowserModel struct {
@@ -107,8 +203,26 @@ type PlaylistBrowserModel struct {
 	keys      playlistKeyMap
 	confirmed bool
 	cancelled bool
+	embedded  bool
+	preview   *Preview
+	details   *playlistDetails
+	sortKey   PlaylistSort
+	reverse   bool
+	anchor    string
+	prompt    textinput.Model
+	prompting bool
+	longer    bool
+	err       error
+	width     int
+	height    int
 }
 
+const (
+	previewPaneMin = 90
+	previewPaneMax = 44
+	previewGap     = 2
+)
+
 func NewPlaylistBrowser(title string, items []adapter.MediaInfo) PlaylistBrowserModel {
 	selected := make(map[string]bool)
 
@@ -116,62 +230,193 @@ func NewPlaylistBrowser(title string, items []adapter.MediaInfo) PlaylistBrowser
 
 	listItems := make([]list.Item, len(items))
 	for i, item := range items {
-		listItems[i] = PlaylistItem{info: item}
+		listItems[i] = PlaylistItem{info: item, index: i + 1}
 	}
 
 	l := list.New(listItems, delegate, 80, 20)
+	l.KeyMap = playlistListKeys
+	l.DisableQuitKeybindings()
 	l.Title = title
 	l.SetShowStatusBar(true)
 	l.SetFilteringEnabled(true)
 	l.SetShowHelp(true)
 
-	l.AdditionalShortHelpKeys = func() []key.Binding {
-		return []key.Binding{playlistKeys.Toggle, playlistKeys.All}
-	}
-	l.AdditionalFullHelpKeys = func() []key.Binding {
-		return []key.Binding{playlistKeys.Toggle, playlistKeys.All, playlistKeys.None, playlistKeys.Invert}
-	}
+	prompt := textinput.New()
+	prompt.Placeholder = "10m"
 
-	return PlaylistBrowserModel{
+	m := PlaylistBrowserModel{
 		list:     l,
 		items:    items,
 		selected: selected,
 		delegate: delegate,
 		keys:     playlistKeys,
+		prompt:   prompt,
+	}
+	m.setHelpKeys()
+	return m
+}
+
+func (m *PlaylistBrowserModel) setHelpKeys() {
+	keys := m.keys
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.Toggle, keys.All, keys.Sort, keys.Confirm, keys.Quit}
+	}
+	m.list.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keys.Toggle, keys.RangeUp, keys.RangeDown, keys.Range,
+			keys.All, keys.None, keys.Invert, keys.Shorter, keys.Longer,
+			keys.Sort, keys.Reverse, keys.Confirm, keys.Quit,
+		}
+	}
+}
+
+func (m PlaylistBrowserModel) WithSelected(ids []string) PlaylistBrowserModel {
+	for _, id := range ids {
+		m.selected[id] = true
+	}
+	return m
+}
+
+func (m PlaylistBrowserModel) Embedded() PlaylistBrowserModel {
+	m.embedded = true
+	setDesc(&m.keys.Quit, "back")
+	m.setHelpKeys()
+	return m
+}
+
+func (m PlaylistBrowserModel) WithPreview(preview *Preview) PlaylistBrowserModel {
+	m.preview = preview
+	return m
+}
+
+func (m PlaylistBrowserModel) WithDetails(fetch func(ctx context.Context, url string) (*adapter.MediaInfo, error)) PlaylistBrowserModel {
+	m.details = &playlistDetails{
+		fetch:  fetch,
+		info:   make(map[string]*adapter.MediaInfo),
+		sizes:  make(map[string]sizeEstimate),
+		failed: make(map[string]bool),
 	}
+	return m
 }
 
 func (m PlaylistBrowserModel) Init() tea.Cmd {
+	return m.load()
+}
+
+func (m PlaylistBrowserModel) paneWidth() int {
+	if (!m.preview.Enabled() && m.details == nil) || m.width < previewPaneMin {
+		return 0
+	}
+	return min(m.width/3, previewPaneMax)
+}
+
+func (m PlaylistBrowserModel) current() *adapter.MediaInfo {
+	if item, ok := m.list.SelectedItem().(PlaylistItem); ok {
+		return &item.info
+	}
 	return nil
 }
 
+func (m PlaylistBrowserModel) load() tea.Cmd {
+	var cmd tea.Cmd
+	if info := m.current(); info != nil {
+		cmd = m.preview.Load(info)
+	}
+	return tea.Batch(cmd, m.fetchDetails())
+}
+
+func (m PlaylistBrowserModel) fetchDetails() tea.Cmd {
+	d := m.details
+	if d == nil || d.pending != "" {
+		return nil
+	}
+
+	target := m.current()
+	if target == nil || !d.missing(target.ID) {
+		target = nil
+		for i := range m.items {
+			if m.selected[m.items[i].ID] && d.missing(m.items[i].ID) {
+				target = &m.items[i]
+				break
+			}
+		}
+	}
+	if target == nil {
+		return nil
+	}
+	d.pending = target.ID
+
+	id, url, fetch := target.ID, target.URL, d.fetch
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), detailTimeout)
+		defer cancel()
+		info, err := fetch(ctx, url)
+		return playlistDetailMsg{id: id, info: info, err: err}
+	}
+}
+
 func (m PlaylistBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.list.SetWidth(msg.Width)
+		m.width, m.height = msg.Width, msg.Height
+		width := msg.Width
+		if pane := m.paneWidth(); pane > 0 {
+			width -= pane + previewGap
+		}
+		m.list.SetWidth(width)
 		m.list.SetHeight(msg.Height - 4)
 
+	case ThumbnailMsg:
+		m.preview.Update(msg)
+		return m, nil
+
+	case playlistDetailMsg:
+		if m.details == nil {
+			return m, nil
+		}
+		m.details.update(msg)
+		return m, m.fetchDetails()
+
 	case tea.KeyMsg:
+		if m.prompting {
+			return m.updatePrompt(msg)
+		}
 		if m.list.FilterState() == list.Filtering {
 			break
 		}
+		m.err = nil
 
 		switch {
 		case key.Matches(msg, m.keys.Quit):
 			m.cancelled = true
-			return m, tea.Quit
+			return m, finish(m.embedded, BackMsg{})
 
 		case key.Matches(msg, m.keys.Toggle):
-			if item, ok := m.list.SelectedItem().(PlaylistItem); ok {
-				m.selected[item.info.ID] = !m.selected[item.info.ID]
+			if info := m.current(); info != nil {
+				m.selected[info.ID] = !m.selected[info.ID]
+				m.anchor = info.ID
 			}
-			return m, nil
+			return m, m.fetchDetails()
+
+		case key.Matches(msg, m.keys.RangeUp, m.keys.RangeDown):
+			m.selectCurrent()
+			if key.Matches(msg, m.keys.RangeUp) {
+				m.list.CursorUp()
+			} else {
+				m.list.CursorDown()
+			}
+			m.selectCurrent()
+			return m, m.load()
+
+		case key.Matches(msg, m.keys.Range):
+			m.selectRange()
+			return m, m.fetchDetails()
 
 		case key.Matches(msg, m.keys.All):
 			for _, item := range m.items {
 				m.selected[item.ID] = true
 			}
-			return m, nil
+			return m, m.fetchDetails()
 
 		case key.Matches(msg, m.keys.None):
 			m.selected = make(map[string]bool)
@@ -182,26 +427,333 @@ func (m PlaylistBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			for _, item := range m.items {
 				m.selected[item.ID] = !m.selected[item.ID]
 			}
-			return m, nil
+			return m, m.fetchDetails()
+
+		case key.Matches(msg, m.keys.Shorter, m.keys.Longer):
+			m.longer = key.Matches(msg, m.keys.Longer)
+			m.prompt.Prompt = "Select where duration < "
+			if m.longer {
+				m.prompt.Prompt = "Select where duration > "
+			}
+			m.prompt.SetValue("")
+			m.prompting = true
+			return m, m.prompt.Focus()
+
+		case key.Matches(msg, m.keys.Sort):
+			m.sortKey = (m.sortKey + 1) % 4
+			return m, m.applySort()
+
+		case key.Matches(msg, m.keys.Reverse):
+			m.reverse = !m.reverse
+			return m, m.applySort()
 
 		case key.Matches(msg, m.keys.Confirm):
 			m.confirmed = true
-			return m, tea.Quit
+			return m, finish(m.embedded, PlaylistSelectedMsg{Items: m.SelectedItems()})
 		}
 	}
 
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
+	return m, tea.Batch(cmd, m.load())
+}
+
+func (m PlaylistBrowserModel) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.prompting = false
+		m.prompt.Blur()
+		return m, nil
+
+	case tea.KeyEnter:
+		limit, err := match.ParseDuration(m.prompt.Value())
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.prompting = false
+		m.err = nil
+		m.prompt.Blur()
+		m.selectDuration(limit)
+		return m, m.fetchDetails()
+	}
+
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
 	return m, cmd
 }
 
+func (m *PlaylistBrowserModel) selectCurrent() {
+	if info := m.current(); info != nil {
+		m.selected[info.ID] = true
+		m.anchor = info.ID
+	}
+}
+
+func (m *PlaylistBrowserModel) selectRange() {
+	visible := m.list.VisibleItems()
+	cursor := m.list.Index()
+	from := -1
+	for i, item := range visible {
+		if item.(PlaylistItem).info.ID == m.anchor {
+			from = i
+			break
+		}
+	}
+	if from < 0 || cursor < 0 || cursor >= len(visible) {
+		m.selectCurrent()
+		return
+	}
+
+	state := m.selected[m.anchor]
+	for i := min(from, cursor); i <= max(from, cursor); i++ {
+		m.selected[visible[i].(PlaylistItem).info.ID] = state
+	}
+	m.anchor = visible[cursor].(PlaylistItem).info.ID
+}
+
+func (m *PlaylistBrowserModel) selectDuration(limit time.Duration) {
+	m.selected = make(map[string]bool)
+	m.delegate.selected = m.selected
+	for _, item := range m.items {
+		if item.Duration <= 0 {
+			continue
+		}
+		d := time.Duration(item.Duration) * time.Second
+		if m.longer && d > limit || !m.longer && d < limit {
+			m.selected[item.ID] = true
+		}
+	}
+}
+
+func (m *PlaylistBrowserModel) applySort() tea.Cmd {
+	var current string
+	if info := m.current(); info != nil {
+		current = info.ID
+	}
+
+	items := make([]PlaylistItem, len(m.items))
+	for i, info := range m.items {
+		items[i] = PlaylistItem{info: info, index: i + 1}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if m.sortKey == PlaylistSortDate {
+			if za, zb := m.uploadDate(a.info).IsZero(), m.uploadDate(b.info).IsZero(); za != zb {
+				return zb
+			}
+		}
+		c := m.compare(a, b)
+		if m.reverse {
+			c = -c
+		}
+		if c == 0 {
+			return a.index < b.index
+		}
+		return c < 0
+	})
+
+	listItems := make([]list.Item, len(items))
+	for i, item := range items {
+		listItems[i] = item
+	}
+	cmd := m.list.SetItems(listItems)
+	for i, item := range m.list.VisibleItems() {
+		if item.(PlaylistItem).info.ID == current {
+			m.list.Select(i)
+			break
+		}
+	}
+	return tea.Batch(cmd, m.load())
+}
+
+func (m PlaylistBrowserModel) compare(a, b PlaylistItem) int {
+	switch m.sortKey {
+	case PlaylistSortTitle:
+		return cmp.Compare(strings.ToLower(a.info.Title), strings.ToLower(b.info.Title))
+	case PlaylistSortDuration:
+		return cmp.Compare(a.info.Duration, b.info.Duration)
+	case PlaylistSortDate:
+		return m.uploadDate(a.info).Compare(m.uploadDate(b.info))
+	default:
+		return cmp.Compare(a.index, b.index)
+	}
+}
+
+func (m PlaylistBrowserModel) uploadDate(info adapter.MediaInfo) time.Time {
+	if detail := m.details.get(info.ID); detail != nil && !detail.UploadDate.IsZero() {
+		return detail.UploadDate
+	}
+	return info.UploadDate
+}
+
+func (m PlaylistBrowserModel) status() string {
+	count := m.SelectedCount()
+	parts := []string{fmt.Sprintf("%d of %d selected", count, len(m.items))}
+
+	if m.details != nil && count > 0 {
+		var total int64
+		approx := false
+		sized := 0
+		for _, item := range m.items {
+			if est, ok := m.details.sizes[item.ID]; ok && m.selected[item.ID] {
+				total += est.size
+				approx = approx || est.approx
+				sized++
+			}
+		}
+		switch {
+		case sized == count:
+			parts = append(parts, formatEstimate(total, approx))
+		case sized > 0:
+			parts = append(parts, fmt.Sprintf("%s (%d of %d sized)", formatEstimate(total, true), sized, count))
+		default:
+			parts = append(parts, "size unknown")
+		}
+	}
+
+	if m.sortKey != PlaylistSortIndex || m.reverse {
+		sortPart := "Sort: " + m.sortKey.String()
+		if m.reverse {
+			sortPart += " (reversed)"
+		}
+		parts = append(parts, sortPart)
+	}
+	return strings.Join(parts, " | ")
+}
+
 func (m PlaylistBrowserModel) View() string {
 	var b strings.Builder
 
-	count := m.SelectedCount()
-	b.WriteString(fmt.Sprintf("%d of %d selected\n\n", count, len(m.items)))
-	b.WriteString(m.list.View())
+	errorStyle := styles.Error
+	b.WriteString(m.status() + "\n")
+	switch {
+	case m.prompting && m.err != nil:
+		b.WriteString(m.prompt.View() + "  " + errorStyle.Render(m.err.Error()))
+	case m.prompting:
+		b.WriteString(m.prompt.View())
+	}
+	b.WriteString("\n")
+
+	pane := m.paneWidth()
+	if pane == 0 {
+		b.WriteString(m.list.View())
+		return b.String()
+	}
+
+	listWidth := m.width - pane - previewGap
+	side := m.paneView(pane)
+	for i, line := range strings.Split(m.list.View(), "\n") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line)
+		if i < len(side) {
+			b.WriteString(strings.Repeat(" ", max(listWidth-lipgloss.Width(line), 0)+previewGap))
+			b.WriteString(side[i])
+		}
+	}
+	return b.String()
+}
+
+func (m PlaylistBrowserModel) paneView(width int) []string {
+	info := m.current()
+	if info == nil {
+		return nil
+	}
+	detail := m.details.get(info.ID)
+
+	titleStyle := styles.Title
+	faintStyle := styles.Muted
+
+	var lines []string
+	if block := m.preview.Thumbnail(info, width, width/3); block.Rows > 0 {
+		lines = append(append(lines, block.Lines...), "")
+	}
+	lines = append(lines, titleStyle.Render(truncateWidth(info.Title, width)))
+
+	uploader := info.Uploader
+	if detail != nil && detail.Uploader != "" {
+		uploader = detail.Uploader
+	}
+	var meta []string
+	if info.Duration > 0 {
+		meta = append(meta, tui.FormatDuration(info.Duration))
+	}
+	if uploader != "" {
+		meta = append(meta, uploader)
+	}
+	if len(meta) > 0 {
+		lines = append(lines, faintStyle.Render(truncateWidth(strings.Join(meta, " · "), width)))
+	}
 
+	switch {
+	case m.details == nil:
+	case detail != nil:
+		lines = append(append(lines, ""), m.detailView(detail, width)...)
+	case m.details.failed[info.ID]:
+		lines = append(lines, "", faintStyle.Render("Details unavailable"))
+	default:
+		lines = append(lines, "", faintStyle.Render("Loading details..."))
+	}
+	return lines
+}
+
+func (m PlaylistBrowserModel) detailView(info *adapter.MediaInfo, width int) []string {
+	labelStyle := styles.Muted.Width(11)
+
+	var lines []string
+	addRow := func(label, value string) {
+		lines = append(lines, labelStyle.Render(label)+truncateWidth(value, max(width-11, 1)))
+	}
+	if info.ViewCount > 0 {
+		addRow("Views", formatCount(info.ViewCount))
+	}
+	if !info.UploadDate.IsZero() {
+		addRow("Published", info.UploadDate.Format("2006-01-02"))
+	}
+	if qualities := formatQualities(info.Formats); qualities != "" {
+		addRow("Qualities", qualities)
+	}
+	if est, ok := m.details.sizes[info.ID]; ok {
+		addRow("Size", formatEstimate(est.size, est.approx))
+	}
+	return lines
+}
+
+func formatQualities(formats []adapter.Format) string {
+	seen := make(map[int]bool)
+	var heights []int
+	for _, f := range formats {
+		if f.Height > 0 && !seen[f.Height] {
+			seen[f.Height] = true
+			heights = append(heights, f.Height)
+		}
+	}
+	if len(heights) == 0 {
+		if len(formats) > 0 {
+			return "audio only"
+		}
+		return ""
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(heights)))
+	parts := make([]string, len(heights))
+	for i, h := range heights {
+		parts[i] = fmt.Sprintf("%dp", h)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatCount(n int64) string {
+	s := fmt.Sprint(n)
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
 	return b.String()
 }
 
@@ -0,0 +1,167 @@
+package views
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aiomayo/aiodl/internal/adapter"
+	"github.com/aiomayo/aiodl/internal/tui/graphics"
+)
+
+const (
+	thumbnailTimeout = 10 * time.Second
+	thumbnailWidth   = 320
+	infoThumbCols    = 32
+	infoThumbRows    = 10
+)
+
+type ThumbnailMsg struct {
+	URL   string
+	Image *graphics.Image
+	Err   error
+}
+
+type blockKey struct {
+	url        string
+	cols, rows int
+}
+
+type Preview struct {
+	loader   *graphics.Loader
+	renderer *graphics.Renderer
+	images   map[string]*graphics.Image
+	pending  map[string]bool
+	failed   map[string]bool
+	blocks   map[blockKey]graphics.Block
+}
+
+func NewPreview(loader *graphics.Loader, renderer *graphics.Renderer) *Preview {
+	return &Preview{
+		loader:   loader,
+		renderer: renderer,
+		images:   make(map[string]*graphics.Image),
+		pending:  make(map[string]bool),
+		failed:   make(map[string]bool),
+		blocks:   make(map[blockKey]graphics.Block),
+	}
+}
+
+func (p *Preview) Enabled() bool {
+	return p != nil && p.renderer.Protocol() != graphics.None
+}
+
+func (p *Preview) Load(info *adapter.MediaInfo) tea.Cmd {
+	url := ThumbnailURL(info)
+	if !p.Enabled() || url == "" || p.images[url] != nil || p.pending[url] || p.failed[url] {
+		return nil
+	}
+	p.pending[url] = true
+
+	loader := p.loader
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), thumbnailTimeout)
+		defer cancel()
+		img, err := loader.Load(ctx, url)
+		return ThumbnailMsg{URL: url, Image: img, Err: err}
+	}
+}
+
+func (p *Preview) Fetch(ctx context.Context, info *adapter.MediaInfo) error {
+	url := ThumbnailURL(info)
+	if !p.Enabled() || url == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, thumbnailTimeout)
+	defer cancel()
+	img, err := p.loader.Load(ctx, url)
+	p.Update(ThumbnailMsg{URL: url, Image: img, Err: err})
+	return err
+}
+
+func (p *Preview) Update(msg ThumbnailMsg) {
+	if p == nil {
+		return
+	}
+	delete(p.pending, msg.URL)
+	if msg.Err != nil {
+		p.failed[msg.URL] = true
+		return
+	}
+	p.images[msg.URL] = msg.Image
+}
+
+func (p *Preview) Thumbnail(info *adapter.MediaInfo, cols, rows int) graphics.Block {
+	if !p.Enabled() {
+		return graphics.Block{}
+	}
+	url := ThumbnailURL(info)
+	img := p.images[url]
+	if img == nil {
+		return graphics.Block{}
+	}
+
+	key := blockKey{url: url, cols: cols, rows: rows}
+	block, ok := p.blocks[key]
+	if !ok {
+		block = p.renderer.Render(img, cols, rows)
+		p.blocks[key] = block
+	}
+	return block
+}
+
+func ThumbnailURL(info *adapter.MediaInfo) string {
+	if info == nil {
+		return ""
+	}
+
+	var best *adapter.Thumbnail
+	for i := range info.Thumbnails {
+		t := &info.Thumbnails[i]
+		if t.URL == "" || strings.Contains(t.URL, ".webp") {
+			continue
+		}
+		switch {
+		case best == nil:
+			best = t
+		case best.Width < thumbnailWidth:
+			if t.Width > best.Width {
+				best = t
+			}
+		case t.Width >= thumbnailWidth && t.Width < best.Width:
+			best = t
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.URL
+}
+
+func (p *Preview) RenderInfo(info *adapter.MediaInfo) string {
+	return RenderInfoPreview(info, p.Thumbnail(info, infoThumbCols, infoThumbRows))
+}
+
+func RenderInfoPreview(info *adapter.MediaInfo, block graphics.Block) string {
+	text := RenderInfo(info)
+	if block.Rows == 0 {
+		return text
+	}
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	var b strings.Builder
+	for i := range max(block.Rows, len(lines)) {
+		if i < block.Rows {
+			b.WriteString(block.Lines[i])
+		} else {
+			b.WriteString(strings.Repeat(" ", block.Cols))
+		}
+		if i < len(lines) {
+			b.WriteString("  " + lines[i])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,9 @@
+package views
+
+import "github.com/aiomayo/aiodl/internal/tui/theme"
+
+var styles = theme.Default()
+
+func SetTheme(t theme.Theme) {
+	styles = t
+}
@@ -0,0 +1,67 @@
+package verify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aiomayo/aiodl/internal/outfile"
+)
+
+const SidecarExt = ".sha256"
+
+func SidecarPath(path string) string {
+	return path + SidecarExt
+}
+
+func Checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func WriteSidecar(path string) (string, error) {
+	sum, err := Checksum(path)
+	if err != nil {
+		return "", err
+	}
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(path))
+	return sum, outfile.WriteFile(SidecarPath(path), []byte(line), 0o644)
+}
+
+func CheckSidecar(path string) (string, error) {
+	data, err := os.ReadFile(SidecarPath(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	want, _, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	if len(want) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid checksum file %s", SidecarPath(path))
+	}
+	got, err := Checksum(path)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(got, want) {
+		return got, corruptf("sha256 %s does not match %s", got, SidecarPath(path))
+	}
+	return got, nil
+}
@@ -0,0 +1,350 @@
+package verify
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const maxBoxRead = 64 * 1024 * 1024
+
+type box struct {
+	typ    string
+	start  int64
+	header int64
+	size   int64
+}
+
+func (b box) end() int64 { return b.start + b.size }
+
+func readBoxHeader(r io.ReaderAt, off, fileSize int64) (box, error) {
+	if off+8 > fileSize {
+		return box{}, truncatedf("partial box header at offset %d", off)
+	}
+	var buf [16]byte
+	if _, err := r.ReadAt(buf[:8], off); err != nil {
+		return box{}, err
+	}
+	b := box{typ: string(buf[4:8]), start: off, header: 8, size: int64(binary.BigEndian.Uint32(buf[:4]))}
+	switch b.size {
+	case 0:
+		b.size = fileSize - off
+	case 1:
+		if off+16 > fileSize {
+			return box{}, truncatedf("partial %s box header at offset %d", b.typ, off)
+		}
+		if _, err := r.ReadAt(buf[8:16], off+8); err != nil {
+			return box{}, err
+		}
+		b.header, b.size = 16, int64(binary.BigEndian.Uint64(buf[8:16]))
+	}
+	if b.size < b.header {
+		return box{}, corruptf("%s box at offset %d has invalid size %d", b.typ, off, b.size)
+	}
+	return b, nil
+}
+
+func readBoxBody(r io.ReaderAt, b box) ([]byte, error) {
+	n := b.size - b.header
+	if n > maxBoxRead {
+		return nil, corruptf("%s box at offset %d is too large (%d bytes)", b.typ, b.start, n)
+	}
+	body := make([]byte, n)
+	_, err := r.ReadAt(body, b.start+b.header)
+	return body, err
+}
+
+func checkMP4(r io.ReaderAt, fileSize int64) error {
+	var (
+		moov        []byte
+		hasMdat     bool
+		fragmented  bool
+		mdatPayload int64
+		pending     int64 = -1
+		pendingAt   int64
+	)
+
+	for off := int64(0); off < fileSize; {
+		b, err := readBoxHeader(r, off, fileSize)
+		if err != nil {
+			return err
+		}
+		if b.end() > fileSize {
+			return truncatedf("%s box at offset %d ends at %d, file has %d bytes", b.typ, off, b.end(), fileSize)
+		}
+
+		switch b.typ {
+		case "moov":
+			if moov, err = readBoxBody(r, b); err != nil {
+				return err
+			}
+		case "moof":
+			body, err := readBoxBody(r, b)
+			if err != nil {
+				return err
+			}
+			fragmented = true
+			if pending, err = fragmentBytes(body); err != nil {
+				return err
+			}
+			pendingAt = off
+		case "mdat":
+			hasMdat = true
+			payload := b.size - b.header
+			mdatPayload += payload
+			if pending > payload {
+				return corruptf("fragment at offset %d references %d bytes, mdat holds %d", pendingAt, pending, payload)
+			}
+			pending = -1
+		}
+		off = b.end()
+	}
+
+	switch {
+	case moov == nil:
+		return corruptf("no moov box")
+	case !hasMdat:
+		return corruptf("no mdat box")
+	case pending >= 0:
+		return truncatedf("fragment at offset %d has no media data", pendingAt)
+	}
+	return checkSampleTables(moov, fileSize, mdatPayload, fragmented)
+}
+
+func forEachChild(b []byte, fn func(typ string, body []byte) error) error {
+	for len(b) > 0 {
+		if len(b) < 8 {
+			return corruptf("partial box header")
+		}
+		size := int64(binary.BigEndian.Uint32(b[:4]))
+		typ, header := string(b[4:8]), int64(8)
+		switch size {
+		case 0:
+			size = int64(len(b))
+		case 1:
+			if len(b) < 16 {
+				return corruptf("partial %s box header", typ)
+			}
+			size, header = int64(binary.BigEndian.Uint64(b[8:16])), 16
+		}
+		if size < header || size > int64(len(b)) {
+			return corruptf("%s box has invalid size %d", typ, size)
+		}
+		if err := fn(typ, b[header:size]); err != nil {
+			return err
+		}
+		b = b[size:]
+	}
+	return nil
+}
+
+func findChild(b []byte, path ...string) []byte {
+	for _, name := range path {
+		var found []byte
+		_ = forEachChild(b, func(typ string, body []byte) error {
+			if found == nil && typ == name {
+				found = body
+			}
+			return nil
+		})
+		if found == nil {
+			return nil
+		}
+		b = found
+	}
+	return b
+}
+
+type sampleTable struct {
+	samples     int64
+	bytes       int64
+	timeSamples int64
+	chunks      []int64
+}
+
+func checkSampleTables(moov []byte, fileSize, mdatPayload int64, fragmented bool) error {
+	var total int64
+	track := 0
+	err := forEachChild(moov, func(typ string, trak []byte) error {
+		if typ != "trak" {
+			return nil
+		}
+		track++
+		stbl := findChild(trak, "mdia", "minf", "stbl")
+		if stbl == nil {
+			return corruptf("track %d has no sample table", track)
+		}
+		st, err := parseSampleTable(stbl)
+		if err != nil {
+			return corruptf("track %d: %v", track, err)
+		}
+		if st.samples != st.timeSamples {
+			return corruptf("track %d: stsz lists %d samples, stts %d", track, st.samples, st.timeSamples)
+		}
+		for _, off := range st.chunks {
+			if off >= fileSize {
+				return truncatedf("track %d: chunk at offset %d is past the end of the file", track, off)
+			}
+		}
+		total += st.bytes
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case track == 0:
+		return corruptf("moov has no tracks")
+	case !fragmented && total == 0:
+		return corruptf("no samples")
+	case total > mdatPayload:
+		return truncatedf("samples need %d bytes, mdat holds %d", total, mdatPayload)
+	}
+	return nil
+}
+
+func parseSampleTable(stbl []byte) (*sampleTable, error) {
+	st := &sampleTable{}
+	return st, forEachChild(stbl, func(typ string, b []byte) error {
+		switch typ {
+		case "stsz":
+			if len(b) < 12 {
+				return corruptf("short stsz")
+			}
+			size, count := int64(binary.BigEndian.Uint32(b[4:8])), int64(binary.BigEndian.Uint32(b[8:12]))
+			st.samples = count
+			if size != 0 {
+				st.bytes = size * count
+				return nil
+			}
+			if int64(len(b)-12) < count*4 {
+				return corruptf("stsz lists %d samples but holds %d", count, (len(b)-12)/4)
+			}
+			for i := range count {
+				st.bytes += int64(binary.BigEndian.Uint32(b[12+i*4:]))
+			}
+		case "stts":
+			entries, err := tableEntries(b, 8)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				st.timeSamples += int64(binary.BigEndian.Uint32(e))
+			}
+		case "stco", "co64":
+			width := 4
+			if typ == "co64" {
+				width = 8
+			}
+			entries, err := tableEntries(b, width)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				if width == 4 {
+					st.chunks = append(st.chunks, int64(binary.BigEndian.Uint32(e)))
+				} else {
+					st.chunks = append(st.chunks, int64(binary.BigEndian.Uint64(e)))
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func tableEntries(b []byte, width int) ([][]byte, error) {
+	if len(b) < 8 {
+		return nil, corruptf("short sample table")
+	}
+	count := int(binary.BigEndian.Uint32(b[4:8]))
+	if (len(b)-8)/width < count {
+		return nil, corruptf("sample table lists %d entries but holds %d", count, (len(b)-8)/width)
+	}
+	entries := make([][]byte, count)
+	for i := range entries {
+		entries[i] = b[8+i*width : 8+(i+1)*width]
+	}
+	return entries, nil
+}
+
+func fragmentBytes(moof []byte) (int64, error) {
+	var total int64
+	known := true
+	err := forEachChild(moof, func(typ string, traf []byte) error {
+		if typ != "traf" {
+			return nil
+		}
+		var defaultSize int64 = -1
+		return forEachChild(traf, func(typ string, b []byte) error {
+			switch typ {
+			case "tfhd":
+				if len(b) < 8 {
+					return corruptf("short tfhd")
+				}
+				flags := binary.BigEndian.Uint32(b[:4]) & 0xFFFFFF
+				off := 8
+				for _, f := range []struct {
+					flag uint32
+					size int
+				}{{0x01, 8}, {0x02, 4}, {0x08, 4}} {
+					if flags&f.flag != 0 {
+						off += f.size
+					}
+				}
+				if flags&0x10 != 0 && len(b) >= off+4 {
+					defaultSize = int64(binary.BigEndian.Uint32(b[off:]))
+				}
+			case "trun":
+				n, ok, err := trunBytes(b, defaultSize)
+				if err != nil {
+					return err
+				}
+				total += n
+				known = known && ok
+			}
+			return nil
+		})
+	})
+	if err != nil || !known {
+		return -1, err
+	}
+	return total, nil
+}
+
+func trunBytes(b []byte, defaultSize int64) (int64, bool, error) {
+	if len(b) < 8 {
+		return 0, false, corruptf("short trun")
+	}
+	flags := binary.BigEndian.Uint32(b[:4]) & 0xFFFFFF
+	count := int64(binary.BigEndian.Uint32(b[4:8]))
+	off := 8
+	if flags&0x01 != 0 {
+		off += 4
+	}
+	if flags&0x04 != 0 {
+		off += 4
+	}
+
+	if flags&0x200 == 0 {
+		return defaultSize * count, defaultSize >= 0, nil
+	}
+
+	stride := 0
+	for _, flag := range []uint32{0x100, 0x200, 0x400, 0x800} {
+		if flags&flag != 0 {
+			stride += 4
+		}
+	}
+	sizeAt := 0
+	if flags&0x100 != 0 {
+		sizeAt = 4
+	}
+	if int64(len(b)-off) < count*int64(stride) {
+		return 0, false, corruptf("trun lists %d samples but holds %d", count, (len(b)-off)/stride)
+	}
+	var total int64
+	for i := range count {
+		total += int64(binary.BigEndian.Uint32(b[off+int(i)*stride+sizeAt:]))
+	}
+	return total, true, nil
+}
@@ -0,0 +1,100 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aiomayo/aiodl/internal/sniff"
+)
+
+var (
+	ErrCorrupt   = errors.New("file is corrupt")
+	ErrTruncated = errors.New("file is truncated")
+)
+
+type truncatedError struct {
+	msg string
+}
+
+func (e *truncatedError) Error() string { return ErrTruncated.Error() + ": " + e.msg }
+
+func (e *truncatedError) Is(target error) bool {
+	return target == ErrTruncated || target == ErrCorrupt
+}
+
+type Options struct {
+	Size     int64
+	Checksum bool
+}
+
+type Result struct {
+	Path      string
+	Size      int64
+	Container string
+	Checksum  string
+}
+
+func File(path string, opts Options) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Path: path, Size: st.Size()}
+
+	if err := Size(res.Size, opts.Size); err != nil {
+		return res, err
+	}
+
+	head := make([]byte, sniff.HeaderSize)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return res, err
+	}
+	if res.Container, err = sniff.Detect(head[:n]); err != nil {
+		return res, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	switch res.Container {
+	case "mp4", "m4a", "mov", "3gp":
+		err = checkMP4(f, res.Size)
+	case "webm", "mkv":
+		err = checkWebM(f, res.Size)
+	}
+	if err != nil {
+		return res, err
+	}
+
+	if opts.Checksum {
+		if res.Checksum, err = CheckSidecar(path); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+func Size(actual, expected int64) error {
+	switch {
+	case expected <= 0 || actual == expected:
+		return nil
+	case actual < expected:
+		return truncatedf("%d of %d bytes", actual, expected)
+	default:
+		return fmt.Errorf("%w: %d bytes, expected %d", ErrCorrupt, actual, expected)
+	}
+}
+
+func corruptf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrCorrupt, fmt.Sprintf(format, args...))
+}
+
+func truncatedf(format string, args ...any) error {
+	return &truncatedError{msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,226 @@
+package verify
+
+import (
+	"bytes"
+	"io"
+)
+
+const (
+	ebmlIDHeader             = 0x1A45DFA3
+	ebmlIDSegment            = 0x18538067
+	ebmlIDInfo               = 0x1549A966
+	ebmlIDTracks             = 0x1654AE6B
+	ebmlIDCues               = 0x1C53BB6B
+	ebmlIDCuePoint           = 0xBB
+	ebmlIDCueTrackPositions  = 0xB7
+	ebmlIDCueClusterPosition = 0xF1
+	ebmlIDCluster            = 0x1F43B675
+
+	ebmlUnknownSize = -1
+)
+
+type element struct {
+	id     uint32
+	start  int64
+	header int64
+	size   int64
+}
+
+func (e element) body() int64 { return e.start + e.header }
+func (e element) end() int64  { return e.body() + e.size }
+
+func readElementHeader(r io.ReaderAt, off, fileSize int64) (element, error) {
+	var buf [12]byte
+	n, err := r.ReadAt(buf[:min(int64(len(buf)), fileSize-off)], off)
+	if err != nil && err != io.EOF {
+		return element{}, err
+	}
+	b := buf[:n]
+	if len(b) == 0 {
+		return element{}, truncatedf("partial element header at offset %d", off)
+	}
+
+	idLen := vintLength(b[0])
+	if idLen == 0 || idLen > 4 {
+		return element{}, corruptf("invalid element ID at offset %d", off)
+	}
+	if len(b) <= idLen {
+		return element{}, truncatedf("partial element header at offset %d", off)
+	}
+	sizeLen := vintLength(b[idLen])
+	if sizeLen == 0 {
+		return element{}, corruptf("invalid element size at offset %d", off)
+	}
+	if len(b) < idLen+sizeLen {
+		return element{}, truncatedf("partial element header at offset %d", off)
+	}
+
+	e := element{start: off, header: int64(idLen + sizeLen)}
+	for _, c := range b[:idLen] {
+		e.id = e.id<<8 | uint32(c)
+	}
+	e.size = decodeVintSize(b[idLen : idLen+sizeLen])
+	return e, nil
+}
+
+func vintLength(b byte) int {
+	for i := range 8 {
+		if b&(0x80>>i) != 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func decodeVintSize(b []byte) int64 {
+	v := uint64(b[0] & (0xff >> len(b)))
+	allOnes := v == uint64(0xff>>len(b))
+	for _, c := range b[1:] {
+		v = v<<8 | uint64(c)
+		allOnes = allOnes && c == 0xff
+	}
+	if allOnes {
+		return ebmlUnknownSize
+	}
+	return int64(v)
+}
+
+func checkWebM(r io.ReaderAt, fileSize int64) error {
+	header, err := readElementHeader(r, 0, fileSize)
+	if err != nil {
+		return err
+	}
+	if header.id != ebmlIDHeader || header.size == ebmlUnknownSize {
+		return corruptf("missing EBML header")
+	}
+	if header.end() > fileSize {
+		return truncatedf("EBML header ends at %d, file has %d bytes", header.end(), fileSize)
+	}
+
+	segment, err := readElementHeader(r, header.end(), fileSize)
+	if err != nil {
+		return err
+	}
+	if segment.id != ebmlIDSegment {
+		return corruptf("missing segment")
+	}
+	segmentEnd := fileSize
+	if segment.size != ebmlUnknownSize {
+		if segment.end() > fileSize {
+			return truncatedf("segment ends at %d, file has %d bytes", segment.end(), fileSize)
+		}
+		segmentEnd = segment.end()
+	}
+
+	var (
+		hasInfo, hasTracks bool
+		clusters           int
+		cues               *element
+	)
+	for off := segment.body(); off < segmentEnd; {
+		e, err := readElementHeader(r, off, fileSize)
+		if err != nil {
+			return err
+		}
+		if e.size == ebmlUnknownSize {
+			if e.id != ebmlIDCluster {
+				return corruptf("element %X at offset %d has unknown size", e.id, off)
+			}
+			clusters++
+			break
+		}
+		if e.end() > segmentEnd {
+			return truncatedf("element %X at offset %d ends at %d, segment ends at %d", e.id, off, e.end(), segmentEnd)
+		}
+
+		switch e.id {
+		case ebmlIDInfo:
+			hasInfo = true
+		case ebmlIDTracks:
+			hasTracks = true
+		case ebmlIDCluster:
+			clusters++
+		case ebmlIDCues:
+			cues = &e
+		}
+		off = e.end()
+	}
+
+	switch {
+	case !hasInfo:
+		return corruptf("missing segment info")
+	case !hasTracks:
+		return corruptf("missing tracks")
+	case clusters == 0:
+		return corruptf("no clusters")
+	}
+	if cues != nil {
+		return checkCues(r, *cues, segment.body(), fileSize)
+	}
+	return nil
+}
+
+func checkCues(r io.ReaderAt, cues element, segmentStart, fileSize int64) error {
+	if cues.size > maxBoxRead {
+		return corruptf("cues at offset %d are too large", cues.start)
+	}
+	body := make([]byte, cues.size)
+	if _, err := r.ReadAt(body, cues.body()); err != nil {
+		return err
+	}
+
+	var positions []int64
+	err := forEachElement(body, func(id uint32, b []byte) error {
+		if id != ebmlIDCuePoint {
+			return nil
+		}
+		return forEachElement(b, func(id uint32, b []byte) error {
+			if id != ebmlIDCueTrackPositions {
+				return nil
+			}
+			return forEachElement(b, func(id uint32, b []byte) error {
+				if id == ebmlIDCueClusterPosition {
+					var pos int64
+					for _, c := range b {
+						pos = pos<<8 | int64(c)
+					}
+					positions = append(positions, pos)
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pos := range positions {
+		off := segmentStart + pos
+		if off >= fileSize {
+			return truncatedf("cue points to offset %d, file has %d bytes", off, fileSize)
+		}
+		e, err := readElementHeader(r, off, fileSize)
+		if err != nil || e.id != ebmlIDCluster {
+			return corruptf("cue points to offset %d, which is not a cluster", off)
+		}
+	}
+	return nil
+}
+
+func forEachElement(b []byte, fn func(id uint32, body []byte) error) error {
+	r := bytes.NewReader(b)
+	for off := int64(0); off < int64(len(b)); {
+		e, err := readElementHeader(r, off, int64(len(b)))
+		if err != nil {
+			return err
+		}
+		if e.size == ebmlUnknownSize || e.end() > int64(len(b)) {
+			return corruptf("element %X at offset %d overruns its parent", e.id, off)
+		}
+		if err := fn(e.id, b[e.body():e.end()]); err != nil {
+			return err
+		}
+		off = e.end()
+	}
+	return nil
+}
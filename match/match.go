@@ -0,0 +1,462 @@
+package match
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Type int
+
+const (
+	String Type = iota + 1
+	Number
+	Bool
+	Date
+	Duration
+)
+
+func (t Type) String() string {
+	switch t {
+	case String:
+		return "string"
+	case Number:
+		return "number"
+	case Bool:
+		return "bool"
+	case Date:
+		return "date"
+	case Duration:
+		return "duration"
+	default:
+		return "unknown"
+	}
+}
+
+type Schema map[string]Type
+
+type Fields map[string]any
+
+type Expr struct {
+	src  string
+	root node
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+func (e *Expr) Fields() []string {
+	var names []string
+	e.root.walk(func(c compareNode) {
+		if !slices.Contains(names, c.field) {
+			names = append(names, c.field)
+		}
+	})
+	return names
+}
+
+func (e *Expr) Check(schema Schema) error {
+	var err error
+	e.root.walk(func(c compareNode) {
+		if err == nil {
+			err = c.check(e.src, schema)
+		}
+	})
+	return err
+}
+
+func (e *Expr) Match(fields Fields) bool {
+	return e.root.eval(fields, false) == yes
+}
+
+func (e *Expr) Partial(fields Fields) (matched, known bool) {
+	switch e.root.eval(fields, true) {
+	case yes:
+		return true, true
+	case no:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+type tri int8
+
+const (
+	no tri = iota
+	yes
+	unknown
+)
+
+func triOf(b bool) tri {
+	if b {
+		return yes
+	}
+	return no
+}
+
+type node interface {
+	eval(fields Fields, partial bool) tri
+	walk(fn func(compareNode))
+}
+
+type andNode [2]node
+
+func (n andNode) eval(fields Fields, partial bool) tri {
+	left := n[0].eval(fields, partial)
+	if left == no {
+		return no
+	}
+	right := n[1].eval(fields, partial)
+	switch {
+	case right == no:
+		return no
+	case left == unknown || right == unknown:
+		return unknown
+	}
+	return yes
+}
+
+func (n andNode) walk(fn func(compareNode)) { n[0].walk(fn); n[1].walk(fn) }
+
+type orNode [2]node
+
+func (n orNode) eval(fields Fields, partial bool) tri {
+	left := n[0].eval(fields, partial)
+	if left == yes {
+		return yes
+	}
+	right := n[1].eval(fields, partial)
+	switch {
+	case right == yes:
+		return yes
+	case left == unknown || right == unknown:
+		return unknown
+	}
+	return no
+}
+
+func (n orNode) walk(fn func(compareNode)) { n[0].walk(fn); n[1].walk(fn) }
+
+type notNode struct {
+	n node
+}
+
+func (n notNode) eval(fields Fields, partial bool) tri {
+	switch n.n.eval(fields, partial) {
+	case yes:
+		return no
+	case no:
+		return yes
+	}
+	return unknown
+}
+
+func (n notNode) walk(fn func(compareNode)) { n.n.walk(fn) }
+
+type literal struct {
+	text string
+	pos  int
+}
+
+type compareNode struct {
+	field    string
+	pos      int
+	op       string
+	optional bool
+	value    literal
+	re       *regexp.Regexp
+}
+
+func (c compareNode) walk(fn func(compareNode)) { fn(c) }
+
+func (c compareNode) eval(fields Fields, partial bool) tri {
+	v, ok := lookup(fields, c.field)
+	switch {
+	case !ok && partial:
+		return unknown
+	case !ok:
+		return triOf(c.op != "" && c.optional)
+	case c.op == "":
+		return triOf(truthy(v))
+	}
+	return triOf(c.compare(v))
+}
+
+func lookup(fields Fields, name string) (any, bool) {
+	v, ok := fields[name]
+	if !ok || v == nil {
+		return nil, false
+	}
+	if t, ok := v.(time.Time); ok && t.IsZero() {
+		return nil, false
+	}
+	return v, true
+}
+
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case time.Duration:
+		return v != 0
+	}
+	if n, ok := toNumber(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+func (c compareNode) compare(v any) bool {
+	switch v := v.(type) {
+	case string:
+		return compareString(c.op, v, c.value.text, c.re)
+	case bool:
+		b, ok := parseBool(c.value.text)
+		if !ok {
+			return false
+		}
+		return compareOrdered(c.op, boolInt(v), boolInt(b))
+	case time.Time:
+		if c.re != nil {
+			return compareString(c.op, v.Format("20060102"), "", c.re)
+		}
+		t, err := ParseDate(c.value.text)
+		if err != nil {
+			return false
+		}
+		return compareOrdered(c.op, day(v).Unix(), t.Unix())
+	case time.Duration:
+		d, err := ParseDuration(c.value.text)
+		if err != nil {
+			return false
+		}
+		return compareOrdered(c.op, v, d)
+	}
+	if n, ok := toNumber(v); ok {
+		if c.re != nil {
+			return compareString(c.op, fmt.Sprint(v), "", c.re)
+		}
+		m, ok := ParseNumber(c.value.text)
+		return ok && compareOrdered(c.op, n, m)
+	}
+	return compareString(c.op, fmt.Sprint(v), c.value.text, c.re)
+}
+
+func compareString(op, s, value string, re *regexp.Regexp) bool {
+	switch op {
+	case "=":
+		return s == value
+	case "!=":
+		return s != value
+	case "^=":
+		return strings.HasPrefix(s, value)
+	case "$=":
+		return strings.HasSuffix(s, value)
+	case "*=":
+		return strings.Contains(s, value)
+	case "~=":
+		return re.MatchString(s)
+	case "!~":
+		return !re.MatchString(s)
+	}
+	return false
+}
+
+func compareOrdered[T int64 | float64 | time.Duration](op string, a, b T) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func toNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+var validOps = map[Type][]string{
+	String:   {"=", "!=", "^=", "$=", "*=", "~=", "!~"},
+	Number:   {"=", "!=", "<", "<=", ">", ">=", "~=", "!~"},
+	Bool:     {"=", "!="},
+	Date:     {"=", "!=", "<", "<=", ">", ">=", "~=", "!~"},
+	Duration: {"=", "!=", "<", "<=", ">", ">="},
+}
+
+func (c compareNode) check(src string, schema Schema) error {
+	errorf := func(pos int, format string, args ...any) error {
+		return &SyntaxError{Expr: src, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	typ, ok := schema[c.field]
+	if !ok {
+		if s := suggest(c.field, schema); s != "" {
+			return errorf(c.pos, "unknown field %q (did you mean %q?)", c.field, s)
+		}
+		return errorf(c.pos, "unknown field %q", c.field)
+	}
+	if c.op == "" {
+		return nil
+	}
+	if !slices.Contains(validOps[typ], c.op) {
+		return errorf(c.pos, "operator %q is not supported for %s field %q", c.op, typ, c.field)
+	}
+	if c.re != nil {
+		return nil
+	}
+
+	text := c.value.text
+	switch typ {
+	case Number:
+		if _, ok := ParseNumber(text); !ok {
+			return errorf(c.value.pos, "%s is a number, got %q", c.field, text)
+		}
+	case Bool:
+		if _, ok := parseBool(text); !ok {
+			return errorf(c.value.pos, "%s is a bool, got %q (want true or false)", c.field, text)
+		}
+	case Date:
+		if _, err := ParseDate(text); err != nil {
+			return errorf(c.value.pos, "%s is a date: %v", c.field, err)
+		}
+	case Duration:
+		if _, err := ParseDuration(text); err != nil {
+			return errorf(c.value.pos, "%s is a duration: %v", c.field, err)
+		}
+	}
+	return nil
+}
+
+func suggest(field string, schema Schema) string {
+	best, bestDist := "", 3
+	for name := range schema {
+		if d := distance(field, name); d < bestDist || d == bestDist && best != "" && name < best {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "1":
+		return true, true
+	case "false", "no", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func ParseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	mult := 1.0
+	if s != "" {
+		if i := strings.IndexByte("kKmMgGtT", s[len(s)-1]); i >= 0 {
+			for range i/2 + 1 {
+				mult *= 1000
+			}
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * mult, true
+}
+
+func ParseDate(s string) (time.Time, error) {
+	switch s = strings.TrimSpace(s); s {
+	case "today", "now":
+		return day(time.Now()), nil
+	case "yesterday":
+		return day(time.Now()).AddDate(0, 0, -1), nil
+	}
+	for _, layout := range []string{"20060102", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (want YYYYMMDD, YYYY-MM-DD or today)", s)
+}
+
+func day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	var seconds float64
+	for i, part := range strings.Split(s, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || i > 2 {
+			return 0, fmt.Errorf("invalid duration %q (want 90, 1:30 or 1m30s)", s)
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
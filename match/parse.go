@@ -0,0 +1,280 @@
+package match
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type SyntaxError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("match filter %q: %s at position %d", e.Expr, e.Msg, e.Pos+1)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "~=", "!~", "^=", "$=", "*=", "=", "<", ">"}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '.' || c == ':' || c == '-' || c == '+'
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	errorf := func(pos int, format string, args ...any) error {
+		return &SyntaxError{Expr: src, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case strings.HasPrefix(src[i:], "&&"):
+			tokens = append(tokens, token{kind: tokAnd, text: "&&", pos: i})
+			i += 2
+		case strings.HasPrefix(src[i:], "||"):
+			tokens = append(tokens, token{kind: tokOr, text: "||", pos: i})
+			i += 2
+		case c == '"' || c == '\'':
+			s, n, ok := unquote(src[i:])
+			if !ok {
+				return nil, errorf(i, "unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokString, text: s, pos: i})
+			i += n
+		case isWordByte(c):
+			start := i
+			for i < len(src) && isWordByte(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokWord, text: src[start:i], pos: start})
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			switch {
+			case op != "":
+				if i+len(op) < len(src) && src[i+len(op)] == '?' {
+					op += "?"
+				}
+				tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+				i += len(op)
+			case c == '!':
+				tokens = append(tokens, token{kind: tokNot, text: "!", pos: i})
+				i++
+			default:
+				return nil, errorf(i, "unexpected %q", c)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func unquote(s string) (string, int, bool) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == quote:
+			return b.String(), i + 1, true
+		case c == '\\' && i+1 < len(s):
+			i++
+			if s[i] != quote && s[i] != '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
+
+func Parse(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, p.errorf(p.peek(), "empty expression")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func MustParse(src string) *Expr {
+	e, err := Parse(src)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &SyntaxError{Expr: p.src, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr || p.keyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd || p.keyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) keyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokWord && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokNot || p.keyword("not") {
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected ')'")
+		}
+		return n, nil
+	case tokWord:
+		if !isFieldName(t.text) {
+			return nil, p.errorf(t, "expected field name, got %q", t.text)
+		}
+	case tokEOF:
+		return nil, p.errorf(t, "expected field name")
+	default:
+		return nil, p.errorf(t, "expected field name, got %q", t.text)
+	}
+
+	cmp := compareNode{field: t.text, pos: t.pos}
+	if p.peek().kind != tokOp {
+		return cmp, nil
+	}
+	op := p.next()
+	cmp.op, cmp.optional = strings.CutSuffix(op.text, "?")
+	if cmp.op == "==" {
+		cmp.op = "="
+	}
+
+	value := p.next()
+	if value.kind != tokWord && value.kind != tokString {
+		return nil, p.errorf(value, "expected value after %q", op.text)
+	}
+	cmp.value = literal{text: value.text, pos: value.pos}
+
+	if cmp.op == "~=" || cmp.op == "!~" {
+		re, err := regexp.Compile(value.text)
+		if err != nil {
+			return nil, p.errorf(value, "invalid regexp %q: %v", value.text, err)
+		}
+		cmp.re = re
+	}
+	return cmp, nil
+}
+
+func isFieldName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c == '_' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
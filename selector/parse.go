@@ -0,0 +1,341 @@
+package selector
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type SyntaxError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("format %q: %s at position %d", e.Expr, e.Msg, e.Pos+1)
+}
+
+var keywords = map[string]pickNode{
+	"best":        {kind: pickMuxed},
+	"b":           {kind: pickMuxed},
+	"b*":          {kind: pickAny},
+	"best*":       {kind: pickAny},
+	"worst":       {kind: pickMuxed, worst: true},
+	"w":           {kind: pickMuxed, worst: true},
+	"w*":          {kind: pickAny, worst: true},
+	"worst*":      {kind: pickAny, worst: true},
+	"bestvideo":   {kind: pickVideoOnly},
+	"bv":          {kind: pickVideoOnly},
+	"bv*":         {kind: pickVideo},
+	"bestvideo*":  {kind: pickVideo},
+	"worstvideo":  {kind: pickVideoOnly, worst: true},
+	"wv":          {kind: pickVideoOnly, worst: true},
+	"wv*":         {kind: pickVideo, worst: true},
+	"worstvideo*": {kind: pickVideo, worst: true},
+	"bestaudio":   {kind: pickAudioOnly},
+	"ba":          {kind: pickAudioOnly},
+	"ba*":         {kind: pickAudio},
+	"bestaudio*":  {kind: pickAudio},
+	"worstaudio":  {kind: pickAudioOnly, worst: true},
+	"wa":          {kind: pickAudioOnly, worst: true},
+	"wa*":         {kind: pickAudio, worst: true},
+	"worstaudio*": {kind: pickAudio, worst: true},
+}
+
+func Parse(expr string) (*Expr, error) {
+	p := &parser{src: expr}
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("empty expression")
+	}
+	root, err := p.parseAlt()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	return &Expr{src: expr, root: root}, nil
+}
+
+func MustParse(expr string) *Expr {
+	e, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) eof() bool  { return p.pos >= len(p.src) }
+func (p *parser) peek() byte { return p.src[p.pos] }
+
+func (p *parser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Expr: p.src, Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseAlt() (node, error) {
+	var alts altNode
+	for {
+		n, err := p.parseMerge()
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, n)
+		p.skipSpace()
+		if p.eof() || p.peek() != '/' {
+			break
+		}
+		p.pos++
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return alts, nil
+}
+
+func (p *parser) parseMerge() (node, error) {
+	var parts mergeNode
+	for {
+		n, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, n)
+		p.skipSpace()
+		if p.eof() || p.peek() != '+' {
+			break
+		}
+		p.pos++
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return parts, nil
+}
+
+func (p *parser) parseAtom() (node, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("expected format selector")
+	}
+
+	if p.peek() == '(' {
+		p.pos++
+		n, err := p.parseAlt()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return n, nil
+	}
+
+	pick := pickNode{kind: pickMuxed}
+	start := p.pos
+	for !p.eof() && isWordChar(p.peek()) {
+		p.pos++
+	}
+	word := p.src[start:p.pos]
+	if word != "" {
+		if kw, ok := keywords[strings.ToLower(word)]; ok {
+			pick = kw
+		} else {
+			pick = pickNode{kind: pickID, id: word}
+		}
+	} else if p.peek() != '[' {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+
+	for !p.eof() && p.peek() == '[' {
+		f, err := p.parseFilter()
+		if err != nil {
+			return nil, err
+		}
+		pick.filters = append(pick.filters, f)
+	}
+	return pick, nil
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '-' || c == '*' || c == '.'
+}
+
+var filterPattern = regexp.MustCompile(`^\s*(!?)([a-zA-Z_][a-zA-Z0-9_]*)\s*(?:(!?(?:<=|>=|\^=|\$=|\*=|~=|=|<|>)|!=)(\??)\s*(.*?))?\s*$`)
+
+func (p *parser) parseFilter() (filter, error) {
+	open := p.pos
+	p.pos++
+
+	var quote byte
+	start := p.pos
+	for ; !p.eof(); p.pos++ {
+		c := p.peek()
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ']':
+			body := p.src[start:p.pos]
+			p.pos++
+			return p.compileFilter(open, body)
+		}
+	}
+	p.pos = open
+	return filter{}, p.errorf("unterminated '['")
+}
+
+func (p *parser) compileFilter(pos int, body string) (filter, error) {
+	m := filterPattern.FindStringSubmatch(body)
+	if m == nil {
+		p.pos = pos
+		return filter{}, p.errorf("invalid filter [%s]", body)
+	}
+
+	f := filter{field: canonicalField(m[2]), op: m[3], optional: m[4] == "?"}
+	if f.op == "" {
+		f.op = "exists"
+		f.negate = m[1] == "!"
+		return f, nil
+	}
+	if m[1] == "!" {
+		p.pos = pos
+		return filter{}, p.errorf("unexpected '!' before field %q", m[2])
+	}
+	if f.op != "!=" && strings.HasPrefix(f.op, "!") {
+		f.negate = true
+		f.op = f.op[1:]
+	}
+
+	f.value = unquote(m[5])
+	if n, ok := parseNumber(f.value); ok {
+		f.number, f.numeric = n, true
+	}
+
+	switch f.op {
+	case "<", "<=", ">", ">=":
+		if !f.numeric {
+			p.pos = pos
+			return filter{}, p.errorf("%s needs a numeric value, got %q", f.op, f.value)
+		}
+	case "~=":
+		re, err := regexp.Compile(f.value)
+		if err != nil {
+			p.pos = pos
+			return filter{}, p.errorf("invalid regexp %q: %v", f.value, err)
+		}
+		f.re = re
+	}
+	return f, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+var numberPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([kKmMgGtT]i?)?[bBpP]?$`)
+
+func parseNumber(s string) (float64, bool) {
+	m := numberPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	if m[2] == "" {
+		return n, true
+	}
+	base := 1000.0
+	if strings.HasSuffix(m[2], "i") {
+		base = 1024
+	}
+	for i := 0; i <= strings.IndexByte("kmgt", strings.ToLower(m[2])[0]); i++ {
+		n *= base
+	}
+	return n, true
+}
+
+type filter struct {
+	field    string
+	op       string
+	value    string
+	number   float64
+	numeric  bool
+	negate   bool
+	optional bool
+	re       *regexp.Regexp
+}
+
+func (f filter) matches(c Candidate) bool {
+	v, ok := c.lookup(f.field)
+	if f.op == "exists" {
+		return ok != f.negate
+	}
+	if !ok {
+		return f.optional
+	}
+	return f.compare(v) != f.negate
+}
+
+func (f filter) compare(v any) bool {
+	if f.numeric {
+		if n, ok := toNumber(v); ok {
+			switch f.op {
+			case "=":
+				return n == f.number
+			case "!=":
+				return n != f.number
+			case "<":
+				return n < f.number
+			case "<=":
+				return n <= f.number
+			case ">":
+				return n > f.number
+			case ">=":
+				return n >= f.number
+			}
+		}
+	}
+
+	s := toString(v)
+	switch f.op {
+	case "=":
+		return s == f.value
+	case "!=":
+		return s != f.value
+	case "^=":
+		return strings.HasPrefix(s, f.value)
+	case "$=":
+		return strings.HasSuffix(s, f.value)
+	case "*=":
+		return strings.Contains(s, f.value)
+	case "~=":
+		return f.re.MatchString(s)
+	}
+	return false
+}
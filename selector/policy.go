@@ -0,0 +1,88 @@
+package selector
+
+type Ranker interface {
+	Compare(a, b Candidate) int
+}
+
+type RankerFunc func(a, b Candidate) int
+
+func (f RankerFunc) Compare(a, b Candidate) int {
+	return f(a, b)
+}
+
+type Filter interface {
+	Filter(candidates []Candidate) []int
+}
+
+type Policy struct {
+	VideoCodecs   []string
+	AudioCodecs   []string
+	Containers    []string
+	MaxHeight     int
+	MaxFPS        int
+	NoHDR         bool
+	PreferSmaller bool
+}
+
+func (p Policy) Allows(c Candidate) bool {
+	if h, ok := c.lookup("height"); ok && p.MaxHeight > 0 {
+		if n, _ := toNumber(h); n > float64(p.MaxHeight) {
+			return false
+		}
+	}
+	if fps, ok := c.lookup("fps"); ok && p.MaxFPS > 0 {
+		if n, _ := toNumber(fps); n > float64(p.MaxFPS) {
+			return false
+		}
+	}
+	if dr, ok := c.lookup("dynamic_range"); ok && p.NoHDR {
+		return toString(dr) != "HDR"
+	}
+	return true
+}
+
+func (p Policy) Filter(candidates []Candidate) []int {
+	fits := false
+	for _, c := range candidates {
+		if c.Video && p.Allows(c) {
+			fits = true
+			break
+		}
+	}
+	keep := make([]int, 0, len(candidates))
+	for i, c := range candidates {
+		if !fits || !c.Video || p.Allows(c) {
+			keep = append(keep, i)
+		}
+	}
+	return keep
+}
+
+func (p Policy) SortKeys() []SortKey {
+	keys := []SortKey{{Field: "height"}, {Field: "fps"}}
+	for _, c := range p.VideoCodecs {
+		keys = append(keys, SortKey{Field: "vcodec", Prefer: c})
+	}
+	for _, c := range p.AudioCodecs {
+		keys = append(keys, SortKey{Field: "acodec", Prefer: c})
+	}
+	for _, c := range p.Containers {
+		keys = append(keys, SortKey{Field: "ext", Prefer: c})
+	}
+	if p.PreferSmaller {
+		keys = append(keys, SortKey{Field: "filesize", Ascending: true})
+	}
+	return append(keys, SortKey{Field: "tbr"})
+}
+
+func (p Policy) Compare(a, b Candidate) int {
+	if c := boolCompare(p.Allows(a), p.Allows(b)); c != 0 {
+		return c
+	}
+	return Compare(a, b, p.SortKeys())
+}
+
+var (
+	_ Ranker = Policy{}
+	_ Filter = Policy{}
+)
@@ -0,0 +1,220 @@
+package selector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type QualityMode int
+
+const (
+	QualityDefault QualityMode = iota
+	QualityBest
+	QualityWorst
+	QualityAtMost
+	QualityNearest
+	QualityAudio
+)
+
+type Quality struct {
+	Mode   QualityMode
+	Height int
+}
+
+var namedHeights = map[string]int{
+	"4k": 2160,
+	"8k": 4320,
+	"2k": 1440,
+	"hd": 720,
+	"sd": 480,
+}
+
+func ParseQuality(s string) (Quality, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "":
+		return Quality{}, nil
+	case "best", "b":
+		return Quality{Mode: QualityBest}, nil
+	case "worst", "w":
+		return Quality{Mode: QualityWorst}, nil
+	case "audio", "bestaudio", "ba":
+		return Quality{Mode: QualityAudio}, nil
+	}
+
+	mode := QualityNearest
+	if rest, ok := strings.CutPrefix(s, "<="); ok {
+		mode, s = QualityAtMost, strings.TrimSpace(rest)
+	}
+	height, ok := parseHeight(s)
+	if !ok {
+		return Quality{}, fmt.Errorf("invalid quality %q (want best, worst, audio, 1080p or <=1080p)", s)
+	}
+	return Quality{Mode: mode, Height: height}, nil
+}
+
+func parseHeight(s string) (int, bool) {
+	if h, ok := namedHeights[s]; ok {
+		return h, true
+	}
+	if i := strings.IndexByte(s, 'p'); i > 0 {
+		s = s[:i]
+	}
+	h, err := strconv.Atoi(s)
+	return h, err == nil && h > 0
+}
+
+func (q Quality) String() string {
+	switch q.Mode {
+	case QualityBest:
+		return "best"
+	case QualityWorst:
+		return "worst"
+	case QualityAudio:
+		return "audio"
+	case QualityAtMost:
+		return fmt.Sprintf("<=%dp", q.Height)
+	case QualityNearest:
+		return fmt.Sprintf("%dp", q.Height)
+	}
+	return ""
+}
+
+func (q Quality) Video() bool {
+	switch q.Mode {
+	case QualityBest, QualityWorst, QualityAtMost, QualityNearest:
+		return true
+	}
+	return false
+}
+
+func (q Quality) Select(candidates []Candidate, container string, compare func(a, b int) int) ([]int, string, error) {
+	prefer := func(a, b int) int {
+		if container != "" {
+			ca := strings.EqualFold(candidates[a].Container, container)
+			cb := strings.EqualFold(candidates[b].Container, container)
+			if c := boolCompare(ca, cb); c != 0 {
+				return c
+			}
+		}
+		return compare(a, b)
+	}
+	rank := func(match func(Candidate) bool) []int {
+		var idx []int
+		for i, c := range candidates {
+			if match(c) {
+				idx = append(idx, i)
+			}
+		}
+		sort.SliceStable(idx, func(i, j int) bool {
+			return prefer(idx[i], idx[j]) > 0
+		})
+		return idx
+	}
+
+	var pool []int
+	switch {
+	case q.Mode == QualityAudio:
+		if pool = rank(func(c Candidate) bool { return c.Audio && !c.Video }); len(pool) == 0 {
+			pool = rank(func(c Candidate) bool { return c.Audio })
+		}
+	case q.Video():
+		pool = rank(func(c Candidate) bool { return c.Video })
+	default:
+		if muxed := rank(Candidate.Muxed); len(muxed) > 0 {
+			return muxed[:1], "best muxed format", nil
+		}
+		pool = rank(func(Candidate) bool { return true })
+		if len(pool) > 0 {
+			return pool[:1], "best available format", nil
+		}
+	}
+	if len(pool) == 0 {
+		return nil, "", fmt.Errorf("%w quality %q", ErrNoMatch, q)
+	}
+
+	i, reason := q.pick(candidates, pool)
+	picked := []int{i}
+	if c := candidates[i]; c.Video && !c.Audio {
+		audio := rank(func(a Candidate) bool {
+			return a.Audio && !a.Video && (a.Container == "" || c.Container == "" || a.Container == c.Container)
+		})
+		if len(audio) > 0 {
+			picked = append(picked, audio[0])
+			reason += fmt.Sprintf(" with best %s audio", c.Container)
+		} else {
+			reason += ", no compatible audio"
+		}
+	}
+	if container != "" && !strings.EqualFold(candidates[i].Container, container) {
+		reason += fmt.Sprintf(" (no %s format available)", container)
+	}
+	return picked, reason, nil
+}
+
+func (q Quality) pick(candidates []Candidate, ranked []int) (int, string) {
+	switch q.Mode {
+	case QualityWorst:
+		i := ranked[len(ranked)-1]
+		return i, "lowest available" + heightNote(candidates[i])
+	case QualityAtMost:
+		if i, ok := highestBelow(candidates, ranked, q.Height+1); ok {
+			return i, fmt.Sprintf("best at or below %dp", q.Height)
+		}
+		i := lowestAbove(candidates, ranked, q.Height)
+		return i, fmt.Sprintf("nothing at or below %dp, using lowest available%s", q.Height, heightNote(candidates[i]))
+	case QualityNearest:
+		for _, i := range ranked {
+			if h, ok := candidateHeight(candidates[i]); ok && h == q.Height {
+				return i, fmt.Sprintf("exact match for %dp", q.Height)
+			}
+		}
+		if i, ok := highestBelow(candidates, ranked, q.Height); ok {
+			return i, fmt.Sprintf("%dp unavailable, using nearest lower%s", q.Height, heightNote(candidates[i]))
+		}
+		i := lowestAbove(candidates, ranked, q.Height)
+		return i, fmt.Sprintf("nothing at or below %dp, using nearest higher%s", q.Height, heightNote(candidates[i]))
+	case QualityAudio:
+		return ranked[0], "best audio"
+	}
+	i := ranked[0]
+	return i, "best available" + heightNote(candidates[i])
+}
+
+func highestBelow(candidates []Candidate, ranked []int, limit int) (int, bool) {
+	best, bestHeight := -1, 0
+	for _, i := range ranked {
+		if h, ok := candidateHeight(candidates[i]); ok && h < limit && h > bestHeight {
+			best, bestHeight = i, h
+		}
+	}
+	return best, best >= 0
+}
+
+func lowestAbove(candidates []Candidate, ranked []int, limit int) int {
+	best, bestHeight := ranked[0], 0
+	for _, i := range ranked {
+		if h, ok := candidateHeight(candidates[i]); ok && h > limit && (bestHeight == 0 || h < bestHeight) {
+			best, bestHeight = i, h
+		}
+	}
+	return best
+}
+
+func candidateHeight(c Candidate) (int, bool) {
+	v, ok := c.lookup("height")
+	if !ok {
+		return 0, false
+	}
+	n, ok := toNumber(v)
+	return int(n), ok && n > 0
+}
+
+func heightNote(c Candidate) string {
+	if h, ok := candidateHeight(c); ok {
+		return fmt.Sprintf(" (%dp)", h)
+	}
+	return ""
+}
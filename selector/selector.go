@@ -0,0 +1,242 @@
+package selector
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrNoMatch = errors.New("no format matches")
+
+type Fields map[string]any
+
+type Candidate struct {
+	ID        string
+	Video     bool
+	Audio     bool
+	Container string
+	Fields    Fields
+}
+
+func (c Candidate) Muxed() bool {
+	return c.Video && c.Audio
+}
+
+func (c Candidate) lookup(field string) (any, bool) {
+	field = canonicalField(field)
+	switch field {
+	case "format_id":
+		return c.ID, true
+	}
+	v, ok := c.Fields[field]
+	return v, ok && v != nil
+}
+
+var fieldAliases = map[string]string{
+	"id":       "format_id",
+	"itag":     "format_id",
+	"res":      "height",
+	"size":     "filesize",
+	"br":       "tbr",
+	"bitrate":  "tbr",
+	"codec":    "vcodec",
+	"language": "lang",
+	"channels": "audio_channels",
+}
+
+func canonicalField(field string) string {
+	if alias, ok := fieldAliases[field]; ok {
+		return alias
+	}
+	return field
+}
+
+type Expr struct {
+	src  string
+	root node
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+func (e *Expr) Select(candidates []Candidate, keys []SortKey) ([]int, error) {
+	keys = append(keys[:len(keys):len(keys)], DefaultSort...)
+	return e.SelectFunc(candidates, func(a, b int) int {
+		return Compare(candidates[a], candidates[b], keys)
+	})
+}
+
+func (e *Expr) SelectFunc(candidates []Candidate, compare func(a, b int) int) ([]int, error) {
+	ctx := &evalContext{candidates: candidates, compare: compare}
+	if picked := e.root.eval(ctx); len(picked) > 0 {
+		return picked, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrNoMatch, e.src)
+}
+
+func Select(candidates []Candidate, expr, sortSpec string) ([]int, error) {
+	e, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := ParseSort(sortSpec)
+	if err != nil {
+		return nil, err
+	}
+	return e.Select(candidates, keys)
+}
+
+type evalContext struct {
+	candidates []Candidate
+	compare    func(a, b int) int
+	merging    []int
+}
+
+func (ctx *evalContext) mergeable(c Candidate) bool {
+	for _, i := range ctx.merging {
+		other := ctx.candidates[i]
+		if other.Container != "" && c.Container != "" && other.Container != c.Container {
+			return false
+		}
+	}
+	return true
+}
+
+func (ctx *evalContext) ranked(match func(Candidate) bool) []int {
+	var idx []int
+	for i, c := range ctx.candidates {
+		if ctx.mergeable(c) && match(c) {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return ctx.compare(idx[i], idx[j]) > 0
+	})
+	return idx
+}
+
+type node interface {
+	eval(ctx *evalContext) []int
+}
+
+type altNode []node
+
+func (n altNode) eval(ctx *evalContext) []int {
+	for _, alt := range n {
+		if picked := alt.eval(ctx); len(picked) > 0 {
+			return picked
+		}
+	}
+	return nil
+}
+
+type mergeNode []node
+
+func (n mergeNode) eval(ctx *evalContext) []int {
+	outer := ctx.merging
+	defer func() { ctx.merging = outer }()
+
+	var picked []int
+	for _, part := range n {
+		ctx.merging = append(outer[:len(outer):len(outer)], picked...)
+		p := part.eval(ctx)
+		if len(p) == 0 {
+			return nil
+		}
+		picked = append(picked, p...)
+	}
+	return picked
+}
+
+type pickKind int
+
+const (
+	pickMuxed pickKind = iota
+	pickAny
+	pickVideoOnly
+	pickVideo
+	pickAudioOnly
+	pickAudio
+	pickID
+)
+
+type pickNode struct {
+	kind    pickKind
+	worst   bool
+	id      string
+	filters []filter
+}
+
+func (n pickNode) eval(ctx *evalContext) []int {
+	ranked := ctx.ranked(func(c Candidate) bool {
+		if !n.matchesKind(c) {
+			return false
+		}
+		for _, f := range n.filters {
+			if !f.matches(c) {
+				return false
+			}
+		}
+		return true
+	})
+	if len(ranked) == 0 {
+		return nil
+	}
+	if n.worst {
+		return ranked[len(ranked)-1:]
+	}
+	return ranked[:1]
+}
+
+func (n pickNode) matchesKind(c Candidate) bool {
+	switch n.kind {
+	case pickMuxed:
+		return c.Muxed()
+	case pickVideoOnly:
+		return c.Video && !c.Audio
+	case pickVideo:
+		return c.Video
+	case pickAudioOnly:
+		return c.Audio && !c.Video
+	case pickAudio:
+		return c.Audio
+	case pickID:
+		return c.ID == n.id
+	default:
+		return true
+	}
+}
+
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
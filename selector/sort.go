@@ -0,0 +1,197 @@
+package selector
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SortKey struct {
+	Field     string
+	Prefer    string
+	Ascending bool
+}
+
+func (k SortKey) String() string {
+	s := k.Field
+	if k.Ascending {
+		s = "+" + s
+	}
+	if k.Prefer != "" {
+		s += ":" + k.Prefer
+	}
+	return s
+}
+
+var DefaultSort = []SortKey{
+	{Field: "height"},
+	{Field: "fps"},
+	{Field: "tbr"},
+	{Field: "filesize"},
+}
+
+func ParseSort(spec string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var key SortKey
+		if strings.HasPrefix(part, "+") {
+			key.Ascending = true
+			part = part[1:]
+		}
+		field, prefer, _ := strings.Cut(part, ":")
+		if field == "" {
+			return nil, fmt.Errorf("sort %q: empty field", spec)
+		}
+		for _, c := range field {
+			if !(c >= 'a' && c <= 'z' || c == '_') {
+				return nil, fmt.Errorf("sort %q: invalid field %q", spec, field)
+			}
+		}
+		key.Field = canonicalField(field)
+		key.Prefer = prefer
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func Compare(a, b Candidate, keys []SortKey) int {
+	for _, key := range keys {
+		c := key.compare(a, b)
+		if key.Ascending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (k SortKey) compare(a, b Candidate) int {
+	va, oka := a.lookup(k.Field)
+	vb, okb := b.lookup(k.Field)
+
+	if limit, ok := parseNumber(k.Prefer); ok {
+		return limitCompare(va, oka, vb, okb, limit)
+	}
+	if k.Prefer != "" {
+		return boolCompare(oka && k.prefers(toString(va)), okb && k.prefers(toString(vb)))
+	}
+
+	if c := boolCompare(oka, okb); c != 0 || !oka {
+		return c
+	}
+
+	switch k.Field {
+	case "vcodec", "acodec":
+		return intCompare(CodecRank(toString(va)), CodecRank(toString(vb)))
+	}
+
+	na, numA := toNumber(va)
+	nb, numB := toNumber(vb)
+	if numA && numB {
+		return floatCompare(na, nb)
+	}
+	return 0
+}
+
+func (k SortKey) prefers(value string) bool {
+	switch k.Field {
+	case "vcodec", "acodec":
+		return CodecMatches(value, k.Prefer)
+	}
+	return strings.EqualFold(value, k.Prefer)
+}
+
+func limitCompare(va any, oka bool, vb any, okb bool, limit float64) int {
+	na, numA := toNumber(va)
+	nb, numB := toNumber(vb)
+	numA, numB = oka && numA, okb && numB
+	if c := boolCompare(numA, numB); c != 0 || !numA {
+		return c
+	}
+	if c := boolCompare(na <= limit, nb <= limit); c != 0 {
+		return c
+	}
+	if na <= limit {
+		return floatCompare(na, nb)
+	}
+	return floatCompare(nb, na)
+}
+
+func boolCompare(a, b bool) int {
+	switch {
+	case a && !b:
+		return 1
+	case !a && b:
+		return -1
+	}
+	return 0
+}
+
+func floatCompare(a, b float64) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
+
+func intCompare(a, b int) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
+
+var codecAliases = map[string][]string{
+	"h264": {"avc1", "avc3"},
+	"avc":  {"avc1", "avc3"},
+	"h265": {"hev1", "hvc1"},
+	"hevc": {"hev1", "hvc1"},
+	"av1":  {"av01"},
+	"vp9":  {"vp9", "vp09"},
+	"vp8":  {"vp8", "vp08"},
+	"aac":  {"mp4a"},
+	"mp3":  {"mp3", "mp4a.40.34"},
+}
+
+func CodecMatches(codec, want string) bool {
+	want = strings.ToLower(want)
+	prefixes, ok := codecAliases[want]
+	if !ok {
+		prefixes = []string{want}
+	}
+	for _, p := range prefixes {
+		if hasPrefixFold(codec, p) {
+			return true
+		}
+	}
+	return false
+}
+
+var codecRanks = []struct {
+	codec string
+	rank  int
+}{
+	{"av1", 5}, {"vp9", 4}, {"h265", 3}, {"h264", 2}, {"vp8", 1},
+	{"opus", 5}, {"vorbis", 4}, {"aac", 3}, {"mp3", 2},
+}
+
+func CodecRank(codec string) int {
+	for _, r := range codecRanks {
+		if CodecMatches(codec, r.codec) {
+			return r.rank
+		}
+	}
+	return 0
+}
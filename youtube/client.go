@@ -3,11 +3,14 @@ package youtube
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"regexp"
+	"time"
 )
 
 const (
@@ -15,8 +18,26 @@ const (
 	userAgent = "com.google.android.youtube/19.09.37 (Linux; U; Android 11) gzip"
 )
 
+const (
+	DefaultConnectTimeout        = 15 * time.Second
+	DefaultTLSHandshakeTimeout   = 10 * time.Second
+	DefaultResponseHeaderTimeout = 30 * time.Second
+	DefaultReadTimeout           = 30 * time.Second
+	DefaultStallRetries          = 3
+	DefaultMaxIdleConnsPerHost   = 8
+)
+
 type Client struct {
 	httpClient *http.Client
+	ranker     FormatRanker
+
+	connectTimeout        time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	readTimeout           time.Duration
+	stallRetries          int
+	maxIdleConnsPerHost   int
+	http2                 bool
 }
 
 type Option func(*Client)
@@ -25,16 +46,70 @@ func WithHTTPClient(client *http.Client) Option {
 	return func(c *Client) { c.httpClient = client }
 }
 
+func WithFormatRanker(r FormatRanker) Option {
+	return func(c *Client) { c.ranker = r }
+}
+
+func WithConnectTimeout(d time.Duration) Option {
+	return func(c *Client) { c.connectTimeout = d }
+}
+
+func WithTLSHandshakeTimeout(d time.Duration) Option {
+	return func(c *Client) { c.tlsHandshakeTimeout = d }
+}
+
+func WithResponseHeaderTimeout(d time.Duration) Option {
+	return func(c *Client) { c.responseHeaderTimeout = d }
+}
+
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *Client) { c.readTimeout = d }
+}
+
+func WithStallRetries(n int) Option {
+	return func(c *Client) { c.stallRetries = n }
+}
+
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(c *Client) { c.maxIdleConnsPerHost = n }
+}
+
+func WithHTTP2(enabled bool) Option {
+	return func(c *Client) { c.http2 = enabled }
+}
+
 func NewClient(opts ...Option) *Client {
 	c := &Client{
-		httpClient: &http.Client{},
+		connectTimeout:        DefaultConnectTimeout,
+		tlsHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		responseHeaderTimeout: DefaultResponseHeaderTimeout,
+		readTimeout:           DefaultReadTimeout,
+		stallRetries:          DefaultStallRetries,
+		maxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		http2:                 true,
 	}
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Transport: c.transport()}
+	}
 	return c
 }
 
+func (c *Client) transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{Timeout: c.connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	t.TLSHandshakeTimeout = c.tlsHandshakeTimeout
+	t.ResponseHeaderTimeout = c.responseHeaderTimeout
+	t.MaxIdleConnsPerHost = c.maxIdleConnsPerHost
+	if !c.http2 {
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t
+}
+
 var (
 	videoIDPattern    = regexp.MustCompile(`(?:v=|youtu\.be/|embed/|shorts/)([a-zA-Z0-9_-]{11})`)
 	playlistIDPattern = regexp.MustCompile(`list=([a-zA-Z0-9_-]+)`)
@@ -2,10 +2,12 @@ package youtube
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 const chunkSize = 10 * 1024 * 1024
@@ -17,17 +19,80 @@ type DownloadOptions struct {
 	MimeType  string
 	Quality   string
 	AudioOnly bool
+	Container string
+	Format    string
+	Sort      string
+	Start     time.Duration
+	End       time.Duration
+	Offset    int64
+}
+
+func (o DownloadOptions) HasSection() bool {
+	return o.Start > 0 || o.End > 0
 }
 
 func (c *Client) Download(ctx context.Context, video *Video, opts DownloadOptions, progress ProgressFunc) (io.ReadCloser, error) {
-	format := selectFormat(video.formats, opts)
-	if format == nil {
-		return nil, ErrNoFormats
+	selection, err := c.SelectFormats(video, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.DownloadSelection(ctx, selection, opts, progress)
+}
+
+func (c *Client) DownloadSelection(ctx context.Context, selection *Selection, opts DownloadOptions, progress ProgressFunc) (io.ReadCloser, error) {
+	formats := selection.Formats
+	switch len(formats) {
+	case 1:
+		if opts.HasSection() {
+			return c.DownloadSection(ctx, &formats[0], opts.Start, opts.End, progress)
+		}
+		return c.DownloadFormatFrom(ctx, &formats[0], opts.Offset, progress)
+	case 2:
+		video, audio := &formats[0], &formats[1]
+		if !video.HasVideo() {
+			video, audio = audio, video
+		}
+		if !video.HasVideo() || audio.HasVideo() || !audio.HasAudio() {
+			return nil, fmt.Errorf("%w: need one video and one audio format", ErrIncompatibleFormats)
+		}
+		return c.DownloadMerged(ctx, video, audio, opts, progress)
+	default:
+		return nil, fmt.Errorf("%w: cannot merge %d formats", ErrIncompatibleFormats, len(formats))
 	}
-	return c.DownloadFormat(ctx, format, progress)
+}
+
+func (c *Client) SelectFormats(video *Video, opts DownloadOptions) (*Selection, error) {
+	fl := video.formats
+	if opts.HasSection() {
+		fl = fl.FilterIndexed()
+	}
+	if opts.Format != "" {
+		formats, err := fl.SelectBy(opts.Format, opts.Sort, c.ranker)
+		if err != nil {
+			return nil, err
+		}
+		return &Selection{Formats: formats, Reason: fmt.Sprintf("matched %q", opts.Format)}, nil
+	}
+	return selectFormat(fl, opts, c.ranker)
+}
+
+func (c *Client) BestFormat(video *Video) *Format {
+	return video.formats.BestBy(c.ranker)
+}
+
+func (c *Client) BestVideo(video *Video) *Format {
+	return video.formats.BestVideoBy(c.ranker)
+}
+
+func (c *Client) BestAudio(video *Video) *Format {
+	return video.formats.BestAudioBy(c.ranker)
 }
 
 func (c *Client) DownloadFormat(ctx context.Context, format *Format, progress ProgressFunc) (io.ReadCloser, error) {
+	return c.DownloadFormatFrom(ctx, format, 0, progress)
+}
+
+func (c *Client) DownloadFormatFrom(ctx context.Context, format *Format, offset int64, progress ProgressFunc) (io.ReadCloser, error) {
 	if format.URL == "" {
 		return nil, ErrNoFormats
 	}
@@ -40,6 +105,17 @@ func (c *Client) DownloadFormat(ctx context.Context, format *Format, progress Pr
 		}
 	}
 
+	if offset > 0 {
+		if offset >= contentLength {
+			return nil, fmt.Errorf("%w: offset %d, length %d", ErrCannotResume, offset, contentLength)
+		}
+		var resumed ProgressFunc
+		if progress != nil {
+			resumed = func(downloaded, _ int64) { progress(offset+downloaded, contentLength) }
+		}
+		return c.downloadSpan(ctx, format.URL, Range{Start: offset, End: contentLength - 1}, resumed), nil
+	}
+
 	if contentLength > chunkSize {
 		return c.downloadChunked(ctx, format.URL, contentLength, progress)
 	}
@@ -62,33 +138,40 @@ func (c *Client) downloadSimple(ctx context.Context, url string, total int64, pr
 		total = resp.ContentLength
 	}
 
+	body := c.watch(resp.Body)
 	if progress == nil {
-		return resp.Body, nil
+		return body, nil
 	}
 
 	return &progressReader{
-		reader:   resp.Body,
+		reader:   body,
 		total:    total,
 		progress: progress,
 	}, nil
 }
 
 func (c *Client) downloadChunked(ctx context.Context, url string, total int64, progress ProgressFunc) (io.ReadCloser, error) {
+	return c.downloadSpan(ctx, url, Range{Start: 0, End: total - 1}, progress), nil
+}
+
+func (c *Client) downloadSpan(ctx context.Context, url string, span Range, progress ProgressFunc) io.ReadCloser {
 	pr, pw := io.Pipe()
+	total := span.Len()
 
 	go func() {
 		defer func() { _ = pw.Close() }()
 		var downloaded int64
+		stalls := 0
 
-		for start := int64(0); start < total; start += chunkSize {
+		for start := span.Start; start <= span.End; {
 			if ctx.Err() != nil {
 				pw.CloseWithError(ctx.Err())
 				return
 			}
 
 			end := start + chunkSize - 1
-			if end >= total {
-				end = total - 1
+			if end > span.End {
+				end = span.End
 			}
 
 			chunk, err := c.downloadRange(ctx, url, start, end)
@@ -99,19 +182,28 @@ func (c *Client) downloadChunked(ctx context.Context, url string, total int64, p
 
 			n, err := io.Copy(pw, chunk)
 			_ = chunk.Close()
-			if err != nil {
-				pw.CloseWithError(err)
-				return
+			downloaded += n
+			if n > 0 {
+				stalls = 0
+				if progress != nil {
+					progress(downloaded, total)
+				}
 			}
 
-			downloaded += n
-			if progress != nil {
-				progress(downloaded, total)
+			switch {
+			case errors.Is(err, ErrStalled) && stalls < c.stallRetries && ctx.Err() == nil:
+				stalls++
+				start += n
+			case err != nil:
+				pw.CloseWithError(err)
+				return
+			default:
+				start = end + 1
 			}
 		}
 	}()
 
-	return pr, nil
+	return pr
 }
 
 func (c *Client) downloadRange(ctx context.Context, url string, start, end int64) (io.ReadCloser, error) {
@@ -133,7 +225,7 @@ func (c *Client) downloadRange(ctx context.Context, url string, start, end int64
 		return nil, fmt.Errorf("http status %d", resp.StatusCode)
 	}
 
-	return resp.Body, nil
+	return c.watch(resp.Body), nil
 }
 
 func (c *Client) getContentLength(ctx context.Context, url string) (int64, error) {
@@ -160,36 +252,31 @@ func (c *Client) getContentLength(ctx context.Context, url string) (int64, error
 	return resp.ContentLength, nil
 }
 
-func selectFormat(formats FormatList, opts DownloadOptions) *Format {
+func selectFormat(formats FormatList, opts DownloadOptions, r FormatRanker) (*Selection, error) {
 	if len(formats) == 0 {
-		return nil
+		return nil, ErrNoFormats
 	}
 
 	if opts.Itag > 0 {
-		return formats.FilterByItag(opts.Itag)
+		f := formats.FilterByItag(opts.Itag)
+		if f == nil {
+			return nil, fmt.Errorf("%w: itag %d", ErrNoFormats, opts.Itag)
+		}
+		return &Selection{Formats: FormatList{*f}, Reason: fmt.Sprintf("itag %d requested", opts.Itag)}, nil
 	}
 
+	quality := opts.Quality
 	if opts.AudioOnly {
-		return formats.FilterAudioOnly().Best()
-	}
-
-	if opts.Quality != "" {
-		if f := formats.FilterByQuality(opts.Quality).Best(); f != nil {
-			return f
-		}
+		quality = "audio"
 	}
 
 	if opts.MimeType != "" {
-		if f := formats.FilterByMimeType(opts.MimeType).Best(); f != nil {
-			return f
+		if fl := formats.FilterByMimeType(opts.MimeType); len(fl) > 0 {
+			formats = fl
 		}
 	}
 
-	if f := formats.FilterMuxed().Best(); f != nil {
-		return f
-	}
-
-	return formats.Best()
+	return formats.PickQuality(quality, opts.Container, r)
 }
 
 type progressReader struct {
@@ -3,12 +3,17 @@ package youtube
 import "errors"
 
 var (
-	ErrVideoNotFound    = errors.New("video not found")
-	ErrVideoPrivate     = errors.New("video is private")
-	ErrVideoUnavailable = errors.New("video is unavailable")
-	ErrAgeRestricted    = errors.New("video is age-restricted")
-	ErrLiveStream       = errors.New("cannot download live streams")
-	ErrPlaylistNotFound = errors.New("playlist not found")
-	ErrInvalidURL       = errors.New("invalid URL")
-	ErrNoFormats        = errors.New("no formats available")
+	ErrVideoNotFound       = errors.New("video not found")
+	ErrVideoPrivate        = errors.New("video is private")
+	ErrVideoUnavailable    = errors.New("video is unavailable")
+	ErrAgeRestricted       = errors.New("video is age-restricted")
+	ErrLiveStream          = errors.New("cannot download live streams")
+	ErrPlaylistNotFound    = errors.New("playlist not found")
+	ErrInvalidURL          = errors.New("invalid URL")
+	ErrNoFormats           = errors.New("no formats available")
+	ErrNoIndex             = errors.New("format has no seek index")
+	ErrInvalidSection      = errors.New("invalid section")
+	ErrIncompatibleFormats = errors.New("formats cannot be merged")
+	ErrCannotResume        = errors.New("cannot resume download")
+	ErrStalled             = errors.New("download stalled")
 )
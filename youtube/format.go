@@ -2,7 +2,10 @@ package youtube
 
 import (
 	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/aiomayo/aiodl/selector"
 )
 
 type FormatList []Format
@@ -37,6 +40,10 @@ func (fl FormatList) FilterByMimeType(mime string) FormatList {
 	return fl.Filter(func(f Format) bool { return strings.Contains(f.MimeType, mime) })
 }
 
+func (fl FormatList) FilterIndexed() FormatList {
+	return fl.Filter(func(f Format) bool { return f.InitRange != nil && f.IndexRange != nil })
+}
+
 func (fl FormatList) FilterByItag(itag int) *Format {
 	for _, f := range fl {
 		if f.ItagNo == itag {
@@ -66,24 +73,128 @@ func (fl FormatList) SortByResolution() FormatList {
 	return result
 }
 
+func (fl FormatList) SortBy(r FormatRanker) FormatList {
+	if r == nil {
+		r = DefaultRanker
+	}
+	result := make(FormatList, len(fl))
+	copy(result, fl)
+	sort.SliceStable(result, func(i, j int) bool {
+		return r.Compare(result[i], result[j]) > 0
+	})
+	return result
+}
+
 func (fl FormatList) Best() *Format {
+	return fl.BestBy(nil)
+}
+
+func (fl FormatList) BestBy(r FormatRanker) *Format {
 	if len(fl) == 0 {
 		return nil
 	}
-	sorted := fl.SortByBitrate()
+	sorted := fl.filterBy(r).SortBy(r)
 	return &sorted[0]
 }
 
 func (fl FormatList) BestVideo() *Format {
+	return fl.BestVideoBy(nil)
+}
+
+func (fl FormatList) BestVideoBy(r FormatRanker) *Format {
 	video := fl.FilterVideoOnly()
 	if len(video) == 0 {
 		video = fl.FilterMuxed()
 	}
-	return video.SortByResolution().Best()
+	return video.BestBy(r)
 }
 
 func (fl FormatList) BestAudio() *Format {
-	return fl.FilterAudioOnly().Best()
+	return fl.BestAudioBy(nil)
+}
+
+func (fl FormatList) BestAudioBy(r FormatRanker) *Format {
+	return fl.FilterAudioOnly().BestBy(r)
+}
+
+func (fl FormatList) Select(expr, sortSpec string) (FormatList, error) {
+	return fl.SelectBy(expr, sortSpec, nil)
+}
+
+func (fl FormatList) SelectBy(expr, sortSpec string, r FormatRanker) (FormatList, error) {
+	e, err := selector.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := selector.ParseSort(sortSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]selector.Candidate, len(fl))
+	for i, f := range fl {
+		candidates[i] = f.Candidate()
+	}
+	picked, err := e.SelectFunc(candidates, func(a, b int) int {
+		if c := selector.Compare(candidates[a], candidates[b], keys); c != 0 {
+			return c
+		}
+		if r != nil {
+			if c := r.Compare(fl[a], fl[b]); c != 0 {
+				return c
+			}
+		}
+		return selector.Compare(candidates[a], candidates[b], selector.DefaultSort)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make(FormatList, len(picked))
+	for i, idx := range picked {
+		result[i] = fl[idx]
+	}
+	return result, nil
+}
+
+func (f Format) Candidate() selector.Candidate {
+	fields := selector.Fields{
+		"ext":  f.Extension(),
+		"mime": f.MimeType,
+	}
+	set := func(name string, value any, ok bool) {
+		if ok {
+			fields[name] = value
+		}
+	}
+	set("vcodec", f.VideoCodec(), f.VideoCodec() != "")
+	set("acodec", f.AudioCodec(), f.AudioCodec() != "")
+	set("height", f.Height, f.Height > 0)
+	set("width", f.Width, f.Width > 0)
+	set("fps", f.FPS, f.FPS > 0)
+	set("tbr", float64(f.Bitrate)/1000, f.Bitrate > 0)
+	set("filesize", f.ContentLength, f.ContentLength > 0)
+	set("audio_channels", f.AudioChannels, f.AudioChannels > 0)
+	set("quality", f.QualityLabel, f.QualityLabel != "")
+	set("format_note", f.QualityLabel, f.QualityLabel != "")
+	set("audio_quality", f.AudioQuality, f.AudioQuality != "")
+	set("lang", f.Language, f.Language != "")
+	if f.HasVideo() {
+		fields["dynamic_range"] = "SDR"
+		if f.HDR {
+			fields["dynamic_range"] = "HDR"
+		}
+	}
+	if asr, err := strconv.Atoi(f.AudioSampleRate); err == nil {
+		fields["asr"] = asr
+	}
+
+	return selector.Candidate{
+		ID:        strconv.Itoa(f.ItagNo),
+		Video:     f.HasVideo(),
+		Audio:     f.HasAudio(),
+		Container: f.Extension(),
+		Fields:    fields,
+	}
 }
 
 func (f Format) HasVideo() bool {
@@ -98,6 +209,44 @@ func (f Format) IsMuxed() bool {
 	return f.HasVideo() && f.AudioChannels > 0
 }
 
+func (f Format) Codecs() []string {
+	_, params, found := strings.Cut(f.MimeType, "codecs=")
+	if !found {
+		return nil
+	}
+	params = strings.Trim(params, `"`)
+	var codecs []string
+	for _, c := range strings.Split(params, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			codecs = append(codecs, c)
+		}
+	}
+	return codecs
+}
+
+func (f Format) VideoCodec() string {
+	codecs := f.Codecs()
+	if !f.HasVideo() || len(codecs) == 0 {
+		return ""
+	}
+	return codecs[0]
+}
+
+func (f Format) AudioCodec() string {
+	codecs := f.Codecs()
+	switch {
+	case !f.HasAudio() || len(codecs) == 0:
+		return ""
+	case f.HasVideo():
+		if len(codecs) < 2 {
+			return ""
+		}
+		return codecs[1]
+	default:
+		return codecs[0]
+	}
+}
+
 func (f Format) Extension() string {
 	mime := f.MimeType
 	if i := strings.Index(mime, "/"); i >= 0 {
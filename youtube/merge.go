@@ -0,0 +1,78 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+type mergeFunc func(dst io.Writer, video, audio io.Reader) error
+
+func mergerFor(video, audio *Format) (mergeFunc, error) {
+	ve, ae := video.Extension(), audio.Extension()
+	if ve != ae {
+		return nil, fmt.Errorf("%w: %s video with %s audio", ErrIncompatibleFormats, ve, ae)
+	}
+	switch ve {
+	case "mp4":
+		return mergeMP4, nil
+	case "webm":
+		return mergeWebM, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported container %q", ErrIncompatibleFormats, ve)
+	}
+}
+
+func (c *Client) DownloadMerged(ctx context.Context, video, audio *Format, opts DownloadOptions, progress ProgressFunc) (io.ReadCloser, error) {
+	merge, err := mergerFor(video, audio)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var downloaded, totals [2]int64
+	report := func(i int) ProgressFunc {
+		return func(done, total int64) {
+			if progress == nil {
+				return
+			}
+			mu.Lock()
+			downloaded[i], totals[i] = done, total
+			d, t := downloaded[0]+downloaded[1], totals[0]+totals[1]
+			mu.Unlock()
+			progress(d, t)
+		}
+	}
+
+	var vr, ar io.ReadCloser
+	if opts.HasSection() {
+		vp, err := c.planSection(ctx, video, opts.Start, opts.End)
+		if err != nil {
+			return nil, err
+		}
+		ap, err := c.planSection(ctx, audio, opts.Start, opts.End)
+		if err != nil {
+			return nil, err
+		}
+		origin := min(vp.origin, ap.origin)
+		vr = c.streamSection(ctx, video, vp, origin, report(0))
+		ar = c.streamSection(ctx, audio, ap, origin, report(1))
+	} else {
+		if vr, err = c.DownloadFormat(ctx, video, report(0)); err != nil {
+			return nil, err
+		}
+		if ar, err = c.DownloadFormat(ctx, audio, report(1)); err != nil {
+			_ = vr.Close()
+			return nil, err
+		}
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer func() { _ = vr.Close() }()
+		defer func() { _ = ar.Close() }()
+		pw.CloseWithError(merge(pw, vr, ar))
+	}()
+	return pr, nil
+}
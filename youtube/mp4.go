@@ -0,0 +1,670 @@
+package youtube
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const maxFragmentHeader = 64 * 1024 * 1024
+
+var errMalformedMP4 = errors.New("malformed mp4")
+
+type boxHeader struct {
+	typ    string
+	size   int64
+	length int
+}
+
+func parseBoxHeader(b []byte) (boxHeader, error) {
+	if len(b) < 8 {
+		return boxHeader{}, errMalformedMP4
+	}
+	h := boxHeader{
+		typ:    string(b[4:8]),
+		size:   int64(binary.BigEndian.Uint32(b)),
+		length: 8,
+	}
+	switch h.size {
+	case 0:
+		h.size = int64(len(b))
+	case 1:
+		if len(b) < 16 {
+			return boxHeader{}, errMalformedMP4
+		}
+		h.size = int64(binary.BigEndian.Uint64(b[8:]))
+		h.length = 16
+	}
+	if h.size < int64(h.length) {
+		return boxHeader{}, errMalformedMP4
+	}
+	return h, nil
+}
+
+func findBox(b []byte, path ...string) (boxHeader, []byte, bool) {
+	var found []byte
+	var header boxHeader
+	_ = forEachBox(b, func(h boxHeader, box []byte) error {
+		if found == nil && h.typ == path[0] {
+			header, found = h, box
+		}
+		return nil
+	})
+	if found == nil {
+		return boxHeader{}, nil, false
+	}
+	if len(path) == 1 {
+		return header, found, true
+	}
+	return findBox(found[header.length:], path[1:]...)
+}
+
+func forEachBox(b []byte, fn func(h boxHeader, box []byte) error) error {
+	for len(b) > 0 {
+		h, err := parseBoxHeader(b)
+		if err != nil {
+			return err
+		}
+		if h.size > int64(len(b)) {
+			return errMalformedMP4
+		}
+		if err := fn(h, b[:h.size]); err != nil {
+			return err
+		}
+		b = b[h.size:]
+	}
+	return nil
+}
+
+type sidxReference struct {
+	size     int64
+	duration uint64
+}
+
+type sidxBox struct {
+	size        int64
+	timescale   uint32
+	earliest    uint64
+	firstOffset uint64
+	references  []sidxReference
+}
+
+func parseSidx(b []byte) (*sidxBox, error) {
+	var sidx *sidxBox
+	err := forEachBox(b, func(h boxHeader, box []byte) error {
+		if h.typ != "sidx" || sidx != nil {
+			return nil
+		}
+		body := box[h.length:]
+		if len(body) < 12 {
+			return errMalformedMP4
+		}
+		sidx = &sidxBox{size: h.size, timescale: binary.BigEndian.Uint32(body[8:])}
+
+		p := 12
+		if body[0] == 0 {
+			if len(body) < p+8 {
+				return errMalformedMP4
+			}
+			sidx.earliest = uint64(binary.BigEndian.Uint32(body[p:]))
+			sidx.firstOffset = uint64(binary.BigEndian.Uint32(body[p+4:]))
+			p += 8
+		} else {
+			if len(body) < p+16 {
+				return errMalformedMP4
+			}
+			sidx.earliest = binary.BigEndian.Uint64(body[p:])
+			sidx.firstOffset = binary.BigEndian.Uint64(body[p+8:])
+			p += 16
+		}
+
+		if len(body) < p+4 {
+			return errMalformedMP4
+		}
+		count := int(binary.BigEndian.Uint16(body[p+2:]))
+		p += 4
+		if len(body) < p+count*12 {
+			return errMalformedMP4
+		}
+		for i := 0; i < count; i++ {
+			sidx.references = append(sidx.references, sidxReference{
+				size:     int64(binary.BigEndian.Uint32(body[p:]) & 0x7fffffff),
+				duration: uint64(binary.BigEndian.Uint32(body[p+4:])),
+			})
+			p += 12
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sidx == nil || sidx.timescale == 0 {
+		return nil, fmt.Errorf("%w: no sidx box", ErrNoIndex)
+	}
+	return sidx, nil
+}
+
+func (s *sidxBox) segments(indexStart int64) []mediaSegment {
+	offset := indexStart + s.size + int64(s.firstOffset)
+	ts := s.earliest
+	toDuration := func(t uint64) time.Duration {
+		return time.Duration(float64(t) / float64(s.timescale) * float64(time.Second))
+	}
+
+	segments := make([]mediaSegment, 0, len(s.references))
+	for _, ref := range s.references {
+		segments = append(segments, mediaSegment{
+			start: toDuration(ts),
+			end:   toDuration(ts + ref.duration),
+			bytes: Range{Start: offset, End: offset + ref.size - 1},
+		})
+		offset += ref.size
+		ts += ref.duration
+	}
+	return segments
+}
+
+type mp4Track struct {
+	id        uint32
+	timescale uint32
+}
+
+func parseTracks(init []byte) ([]mp4Track, error) {
+	h, moov, ok := findBox(init, "moov")
+	if !ok {
+		return nil, fmt.Errorf("%w: no moov box", errMalformedMP4)
+	}
+
+	var tracks []mp4Track
+	err := forEachBox(moov[h.length:], func(h boxHeader, trak []byte) error {
+		if h.typ != "trak" {
+			return nil
+		}
+		var track mp4Track
+		if th, tkhd, ok := findBox(trak[h.length:], "tkhd"); ok {
+			body := tkhd[th.length:]
+			at := 12
+			if len(body) > 0 && body[0] == 1 {
+				at = 20
+			}
+			if len(body) < at+4 {
+				return errMalformedMP4
+			}
+			track.id = binary.BigEndian.Uint32(body[at:])
+		}
+		if mh, mdhd, ok := findBox(trak[h.length:], "mdia", "mdhd"); ok {
+			body := mdhd[mh.length:]
+			at := 12
+			if len(body) > 0 && body[0] == 1 {
+				at = 20
+			}
+			if len(body) < at+4 {
+				return errMalformedMP4
+			}
+			track.timescale = binary.BigEndian.Uint32(body[at:])
+		}
+		if track.timescale == 0 {
+			return fmt.Errorf("%w: track without timescale", errMalformedMP4)
+		}
+		tracks = append(tracks, track)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("%w: no tracks", errMalformedMP4)
+	}
+	return tracks, nil
+}
+
+func planMP4Section(init, index []byte, indexRange Range, start, end time.Duration) (*sectionPlan, error) {
+	tracks, err := parseTracks(init)
+	if err != nil {
+		return nil, err
+	}
+	sidx, err := parseSidx(index)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := selectSegments(sidx.segments(indexRange.Start), start, end)
+	if err != nil {
+		return nil, err
+	}
+	if err := setMP4Duration(init, selected[len(selected)-1].end-selected[0].start); err != nil {
+		return nil, err
+	}
+
+	return &sectionPlan{
+		init:   init,
+		span:   segmentSpan(selected),
+		origin: selected[0].start,
+		rewrite: func(dst io.Writer, src io.Reader, origin time.Duration) error {
+			shifts := make(map[uint32]uint64, len(tracks))
+			for _, t := range tracks {
+				shifts[t.id] = uint64(origin.Seconds() * float64(t.timescale))
+			}
+			return rewriteFragments(dst, src, shifts)
+		},
+	}, nil
+}
+
+func setMP4Duration(init []byte, clip time.Duration) error {
+	mh, mvhd, ok := findBox(init, "moov", "mvhd")
+	if !ok {
+		return fmt.Errorf("%w: no mvhd box", errMalformedMP4)
+	}
+	body := mvhd[mh.length:]
+	scaleAt, width := 12, 4
+	if len(body) > 0 && body[0] == 1 {
+		scaleAt, width = 20, 8
+	}
+	if len(body) < scaleAt+4+width {
+		return errMalformedMP4
+	}
+	ticks := uint64(clip.Seconds() * float64(binary.BigEndian.Uint32(body[scaleAt:])))
+	putDuration(body[scaleAt+4:], ticks, width)
+
+	eh, mehd, ok := findBox(init, "moov", "mvex", "mehd")
+	if !ok {
+		return nil
+	}
+	body = mehd[eh.length:]
+	width = 4
+	if len(body) > 0 && body[0] == 1 {
+		width = 8
+	}
+	if len(body) < 4+width {
+		return errMalformedMP4
+	}
+	putDuration(body[4:], ticks, width)
+	return nil
+}
+
+func putDuration(b []byte, ticks uint64, width int) {
+	if width == 8 {
+		binary.BigEndian.PutUint64(b, ticks)
+		return
+	}
+	binary.BigEndian.PutUint32(b, uint32(ticks))
+}
+
+func rewriteFragments(dst io.Writer, src io.Reader, shifts map[uint32]uint64) error {
+	br := bufio.NewReader(src)
+	hdr := make([]byte, 16)
+
+	for {
+		if _, err := io.ReadFull(br, hdr[:8]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		n := 8
+		if binary.BigEndian.Uint32(hdr) == 1 {
+			if _, err := io.ReadFull(br, hdr[8:16]); err != nil {
+				return err
+			}
+			n = 16
+		}
+		if binary.BigEndian.Uint32(hdr) == 0 {
+			if _, err := dst.Write(hdr[:n]); err != nil {
+				return err
+			}
+			_, err := io.Copy(dst, br)
+			return err
+		}
+
+		h, err := parseBoxHeader(hdr[:n])
+		if err != nil {
+			return err
+		}
+
+		if h.typ != "moof" {
+			if _, err := dst.Write(hdr[:n]); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(dst, br, h.size-int64(n)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if h.size > maxFragmentHeader {
+			return fmt.Errorf("%w: moof too large", errMalformedMP4)
+		}
+		box := make([]byte, h.size)
+		copy(box, hdr[:n])
+		if _, err := io.ReadFull(br, box[n:]); err != nil {
+			return err
+		}
+		if err := rebaseMoof(box[n:], shifts); err != nil {
+			return err
+		}
+		if _, err := dst.Write(box); err != nil {
+			return err
+		}
+	}
+}
+
+func rebaseMoof(moof []byte, shifts map[uint32]uint64) error {
+	return forEachBox(moof, func(h boxHeader, traf []byte) error {
+		if h.typ != "traf" {
+			return nil
+		}
+		var trackID uint32
+		return forEachBox(traf[h.length:], func(h boxHeader, box []byte) error {
+			body := box[h.length:]
+			switch h.typ {
+			case "tfhd":
+				if len(body) < 8 {
+					return errMalformedMP4
+				}
+				trackID = binary.BigEndian.Uint32(body[4:])
+			case "tfdt":
+				if len(body) < 8 {
+					return errMalformedMP4
+				}
+				shift := shifts[trackID]
+				if body[0] == 1 {
+					if len(body) < 12 {
+						return errMalformedMP4
+					}
+					t := binary.BigEndian.Uint64(body[4:])
+					binary.BigEndian.PutUint64(body[4:], t-min(t, shift))
+				} else {
+					t := uint64(binary.BigEndian.Uint32(body[4:]))
+					binary.BigEndian.PutUint32(body[4:], uint32(t-min(t, shift)))
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func makeBox(typ string, children ...[]byte) []byte {
+	size := 8
+	for _, c := range children {
+		size += len(c)
+	}
+	box := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(box, uint32(size))
+	copy(box[4:], typ)
+	for _, c := range children {
+		box = append(box, c...)
+	}
+	return box
+}
+
+func peekBoxHeader(r *bufio.Reader) (boxHeader, error) {
+	hdr, err := r.Peek(8)
+	if err != nil {
+		if len(hdr) == 0 {
+			return boxHeader{}, err
+		}
+		return boxHeader{}, unexpectedEOF(err)
+	}
+	if binary.BigEndian.Uint32(hdr) == 1 {
+		if hdr, err = r.Peek(16); err != nil {
+			return boxHeader{}, unexpectedEOF(err)
+		}
+	}
+	if binary.BigEndian.Uint32(hdr) == 0 {
+		return boxHeader{typ: string(hdr[4:8]), length: 8}, nil
+	}
+	return parseBoxHeader(hdr)
+}
+
+func readBox(r *bufio.Reader) ([]byte, error) {
+	h, err := peekBoxHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if h.size == 0 || h.size > maxFragmentHeader {
+		return nil, fmt.Errorf("%w: unsupported %s box size", errMalformedMP4, h.typ)
+	}
+	box := make([]byte, h.size)
+	if _, err := io.ReadFull(r, box); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return box, nil
+}
+
+type mp4Init struct {
+	ftyp  []byte
+	mvhd  []byte
+	trak  []byte
+	mehd  []byte
+	trex  []byte
+	extra [][]byte
+	track mp4Track
+}
+
+func readMP4Init(r *bufio.Reader) (*mp4Init, error) {
+	init := &mp4Init{}
+	var moov []byte
+	for {
+		h, err := peekBoxHeader(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if h.typ == "moof" {
+			break
+		}
+		box, err := readBox(r)
+		if err != nil {
+			return nil, err
+		}
+		switch h.typ {
+		case "ftyp":
+			init.ftyp = box
+		case "moov":
+			moov = box
+		}
+	}
+	if moov == nil {
+		return nil, fmt.Errorf("%w: no moov box", errMalformedMP4)
+	}
+
+	tracks, err := parseTracks(moov)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) != 1 {
+		return nil, fmt.Errorf("%w: expected one track, found %d", ErrIncompatibleFormats, len(tracks))
+	}
+	init.track = tracks[0]
+
+	mh, _ := parseBoxHeader(moov)
+	err = forEachBox(moov[mh.length:], func(h boxHeader, box []byte) error {
+		switch h.typ {
+		case "mvhd":
+			init.mvhd = box
+		case "trak":
+			init.trak = box
+		case "mvex":
+			return forEachBox(box[h.length:], func(h boxHeader, box []byte) error {
+				switch h.typ {
+				case "mehd":
+					init.mehd = box
+				case "trex":
+					init.trex = box
+				}
+				return nil
+			})
+		default:
+			init.extra = append(init.extra, box)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if init.mvhd == nil || init.trex == nil {
+		return nil, fmt.Errorf("%w: not a fragmented mp4", ErrIncompatibleFormats)
+	}
+	return init, nil
+}
+
+func (m *mp4Init) setTrackID(id uint32) error {
+	th, tkhd, ok := findBox(m.trak[8:], "tkhd")
+	if !ok {
+		return fmt.Errorf("%w: no tkhd box", errMalformedMP4)
+	}
+	body := tkhd[th.length:]
+	at := 12
+	if len(body) > 0 && body[0] == 1 {
+		at = 20
+	}
+	if len(body) < at+4 {
+		return errMalformedMP4
+	}
+	binary.BigEndian.PutUint32(body[at:], id)
+
+	if len(m.trex) < 16 {
+		return errMalformedMP4
+	}
+	binary.BigEndian.PutUint32(m.trex[12:], id)
+	return nil
+}
+
+func mergeMoov(video, audio *mp4Init) ([]byte, error) {
+	if err := video.setTrackID(1); err != nil {
+		return nil, err
+	}
+	if err := audio.setTrackID(2); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(video.mvhd[len(video.mvhd)-4:], 3)
+
+	mvex := makeBox("mvex", video.mehd, video.trex, audio.trex)
+	children := append([][]byte{video.mvhd, video.trak, audio.trak, mvex}, video.extra...)
+	return append(append([]byte(nil), video.ftyp...), makeBox("moov", children...)...), nil
+}
+
+type fragmentStream struct {
+	r         *bufio.Reader
+	trackID   uint32
+	timescale uint32
+	moof      []byte
+	time      float64
+	done      bool
+}
+
+func (s *fragmentStream) advance(w io.Writer) error {
+	for {
+		h, err := peekBoxHeader(s.r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				s.done = true
+				return nil
+			}
+			return err
+		}
+
+		if h.typ == "moof" {
+			if s.moof, err = readBox(s.r); err != nil {
+				return err
+			}
+			return s.retrack()
+		}
+
+		dst := w
+		if h.typ != "mdat" {
+			dst = io.Discard
+		}
+		if h.size == 0 {
+			s.done = true
+			_, err := io.Copy(dst, s.r)
+			return err
+		}
+		if _, err := io.CopyN(dst, s.r, h.size); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+}
+
+func (s *fragmentStream) retrack() error {
+	h, _ := parseBoxHeader(s.moof)
+	return forEachBox(s.moof[h.length:], func(h boxHeader, traf []byte) error {
+		if h.typ != "traf" {
+			return nil
+		}
+		return forEachBox(traf[h.length:], func(h boxHeader, box []byte) error {
+			body := box[h.length:]
+			switch h.typ {
+			case "tfhd":
+				if len(body) < 8 {
+					return errMalformedMP4
+				}
+				binary.BigEndian.PutUint32(body[4:], s.trackID)
+			case "tfdt":
+				if len(body) >= 12 && body[0] == 1 {
+					s.time = float64(binary.BigEndian.Uint64(body[4:])) / float64(s.timescale)
+				} else if len(body) >= 8 {
+					s.time = float64(binary.BigEndian.Uint32(body[4:])) / float64(s.timescale)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func (s *fragmentStream) emit(w io.Writer, seq uint32) error {
+	if mh, mfhd, ok := findBox(s.moof[8:], "mfhd"); ok && len(mfhd) >= mh.length+8 {
+		binary.BigEndian.PutUint32(mfhd[mh.length+4:], seq)
+	}
+	if _, err := w.Write(s.moof); err != nil {
+		return err
+	}
+	return s.advance(w)
+}
+
+func mergeMP4(dst io.Writer, video, audio io.Reader) error {
+	vr, ar := bufio.NewReader(video), bufio.NewReader(audio)
+	vInit, err := readMP4Init(vr)
+	if err != nil {
+		return fmt.Errorf("video: %w", err)
+	}
+	aInit, err := readMP4Init(ar)
+	if err != nil {
+		return fmt.Errorf("audio: %w", err)
+	}
+
+	init, err := mergeMoov(vInit, aInit)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(init); err != nil {
+		return err
+	}
+
+	streams := []*fragmentStream{
+		{r: vr, trackID: 1, timescale: vInit.track.timescale},
+		{r: ar, trackID: 2, timescale: aInit.track.timescale},
+	}
+	for _, s := range streams {
+		if err := s.advance(io.Discard); err != nil {
+			return err
+		}
+	}
+
+	for seq := uint32(1); ; seq++ {
+		var next *fragmentStream
+		for _, s := range streams {
+			if !s.done && (next == nil || s.time < next.time) {
+				next = s
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		if err := next.emit(dst, seq); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package youtube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aiomayo/aiodl/selector"
+)
+
+type Selection struct {
+	Formats FormatList
+	Reason  string
+}
+
+func (s *Selection) String() string {
+	ids := make([]string, len(s.Formats))
+	for i, f := range s.Formats {
+		ids[i] = strconv.Itoa(f.ItagNo)
+	}
+	return strings.Join(ids, "+")
+}
+
+func (fl FormatList) PickQuality(quality, container string, r FormatRanker) (*Selection, error) {
+	q, err := selector.ParseQuality(quality)
+	if err != nil {
+		return nil, err
+	}
+	if len(fl) == 0 {
+		return nil, ErrNoFormats
+	}
+	fl = fl.filterBy(r)
+
+	candidates := make([]selector.Candidate, len(fl))
+	for i, f := range fl {
+		candidates[i] = f.Candidate()
+	}
+	picked, reason, err := q.Select(candidates, container, func(a, b int) int {
+		if r != nil {
+			if c := r.Compare(fl[a], fl[b]); c != 0 {
+				return c
+			}
+		}
+		return selector.Compare(candidates[a], candidates[b], selector.DefaultSort)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoFormats, err)
+	}
+
+	formats := make(FormatList, len(picked))
+	for i, idx := range picked {
+		formats[i] = fl[idx]
+	}
+	return &Selection{Formats: formats, Reason: reason}, nil
+}
@@ -0,0 +1,62 @@
+package youtube
+
+import "github.com/aiomayo/aiodl/selector"
+
+type FormatRanker interface {
+	Compare(a, b Format) int
+}
+
+type FormatFilter interface {
+	Filter(fl FormatList) FormatList
+}
+
+type FormatRankerFunc func(a, b Format) int
+
+func (f FormatRankerFunc) Compare(a, b Format) int {
+	return f(a, b)
+}
+
+var DefaultRanker FormatRanker = FormatRankerFunc(func(a, b Format) int {
+	switch {
+	case a.Bitrate > b.Bitrate:
+		return 1
+	case a.Bitrate < b.Bitrate:
+		return -1
+	}
+	return 0
+})
+
+type RankPolicy selector.Policy
+
+func (p RankPolicy) Allows(f Format) bool {
+	return selector.Policy(p).Allows(f.Candidate())
+}
+
+func (p RankPolicy) Filter(fl FormatList) FormatList {
+	candidates := make([]selector.Candidate, len(fl))
+	for i, f := range fl {
+		candidates[i] = f.Candidate()
+	}
+	idx := selector.Policy(p).Filter(candidates)
+	result := make(FormatList, len(idx))
+	for i, j := range idx {
+		result[i] = fl[j]
+	}
+	return result
+}
+
+func (p RankPolicy) Compare(a, b Format) int {
+	return selector.Policy(p).Compare(a.Candidate(), b.Candidate())
+}
+
+var (
+	_ FormatRanker = RankPolicy{}
+	_ FormatFilter = RankPolicy{}
+)
+
+func (fl FormatList) filterBy(r FormatRanker) FormatList {
+	if f, ok := r.(FormatFilter); ok {
+		return f.Filter(fl)
+	}
+	return fl
+}
@@ -0,0 +1,130 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+const maxIndexSize = 16 * 1024 * 1024
+
+type mediaSegment struct {
+	start time.Duration
+	end   time.Duration
+	bytes Range
+}
+
+type sectionPlan struct {
+	init    []byte
+	span    Range
+	origin  time.Duration
+	rewrite func(dst io.Writer, src io.Reader, origin time.Duration) error
+}
+
+func (c *Client) DownloadSection(ctx context.Context, format *Format, start, end time.Duration, progress ProgressFunc) (io.ReadCloser, error) {
+	plan, err := c.planSection(ctx, format, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return c.streamSection(ctx, format, plan, plan.origin, progress), nil
+}
+
+func (c *Client) streamSection(ctx context.Context, format *Format, plan *sectionPlan, origin time.Duration, progress ProgressFunc) io.ReadCloser {
+	pr, pw := io.Pipe()
+	total := int64(len(plan.init)) + plan.span.Len()
+
+	go func() {
+		written := int64(len(plan.init))
+		if _, err := pw.Write(plan.init); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if progress != nil {
+			progress(written, total)
+		}
+
+		body := c.downloadSpan(ctx, format.URL, plan.span, func(downloaded, _ int64) {
+			if progress != nil {
+				progress(written+downloaded, total)
+			}
+		})
+		defer func() { _ = body.Close() }()
+
+		pw.CloseWithError(plan.rewrite(pw, body, origin))
+	}()
+
+	return pr
+}
+
+func (c *Client) planSection(ctx context.Context, format *Format, start, end time.Duration) (*sectionPlan, error) {
+	if start < 0 || (end > 0 && end <= start) {
+		return nil, ErrInvalidSection
+	}
+	if format.URL == "" {
+		return nil, ErrNoFormats
+	}
+	if format.InitRange == nil || format.IndexRange == nil {
+		return nil, ErrNoIndex
+	}
+
+	contentLength := format.ContentLength
+	if contentLength == 0 {
+		length, err := c.getContentLength(ctx, format.URL)
+		if err == nil && length > 0 {
+			contentLength = length
+		}
+	}
+
+	head := Range{
+		Start: min(format.InitRange.Start, format.IndexRange.Start),
+		End:   max(format.InitRange.End, format.IndexRange.End),
+	}
+	if head.Len() > maxIndexSize {
+		return nil, fmt.Errorf("%w: index too large (%d bytes)", ErrNoIndex, head.Len())
+	}
+
+	body, err := c.downloadRange(ctx, format.URL, head.Start, head.End)
+	if err != nil {
+		return nil, fmt.Errorf("fetch index: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, head.Len()))
+	_ = body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("fetch index: %w", err)
+	}
+	if int64(len(data)) < head.Len() {
+		return nil, fmt.Errorf("fetch index: %w", io.ErrUnexpectedEOF)
+	}
+
+	slice := func(r Range) []byte {
+		return data[r.Start-head.Start : r.End-head.Start+1]
+	}
+	init, index := slice(*format.InitRange), slice(*format.IndexRange)
+
+	switch format.Extension() {
+	case "mp4":
+		return planMP4Section(init, index, *format.IndexRange, start, end)
+	case "webm":
+		return planWebMSection(init, index, format, contentLength, start, end)
+	default:
+		return nil, fmt.Errorf("%w: unsupported container %q", ErrNoIndex, format.MimeType)
+	}
+}
+
+func selectSegments(segments []mediaSegment, start, end time.Duration) ([]mediaSegment, error) {
+	var selected []mediaSegment
+	for _, s := range segments {
+		if s.end > start && (end <= 0 || s.start < end) {
+			selected = append(selected, s)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: %s-%s is outside the media", ErrInvalidSection, start, end)
+	}
+	return selected, nil
+}
+
+func segmentSpan(segments []mediaSegment) Range {
+	return Range{Start: segments[0].bytes.Start, End: segments[len(segments)-1].bytes.End}
+}
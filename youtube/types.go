@@ -11,6 +11,8 @@ type Video struct {
 	AuthorID    string
 	ViewCount   int64
 	PublishDate time.Time
+	IsLive      bool
+	WasLive     bool
 	Thumbnails  []Thumbnail
 	formats     FormatList
 }
@@ -39,6 +41,19 @@ type Format struct {
 	AudioChannels   int
 	ContentLength   int64
 	URL             string
+	Language        string
+	HDR             bool
+	InitRange       *Range
+	IndexRange      *Range
+}
+
+type Range struct {
+	Start int64
+	End   int64
+}
+
+func (r Range) Len() int64 {
+	return r.End - r.Start + 1
 }
 
 type Playlist struct {
@@ -121,7 +136,10 @@ type playerResponse struct {
 	} `json:"streamingData"`
 	Microformat struct {
 		PlayerMicroformatRenderer struct {
-			PublishDate string `json:"publishDate"`
+			PublishDate          string `json:"publishDate"`
+			LiveBroadcastDetails *struct {
+				IsLiveNow bool `json:"isLiveNow"`
+			} `json:"liveBroadcastDetails"`
 		} `json:"playerMicroformatRenderer"`
 	} `json:"microformat"`
 }
@@ -140,6 +158,19 @@ type formatRaw struct {
 	AudioQuality    string `json:"audioQuality"`
 	AudioSampleRate string `json:"audioSampleRate"`
 	AudioChannels   int    `json:"audioChannels"`
+	ColorInfo       *struct {
+		TransferCharacteristics string `json:"transferCharacteristics"`
+	} `json:"colorInfo"`
+	AudioTrack *struct {
+		ID string `json:"id"`
+	} `json:"audioTrack"`
+	InitRange  *rangeRaw `json:"initRange"`
+	IndexRange *rangeRaw `json:"indexRange"`
+}
+
+type rangeRaw struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
 }
 
 type playlistData struct {
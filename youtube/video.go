@@ -107,6 +107,12 @@ func convertPlayerResponse(pr *playerResponse) *Video {
 		video.PublishDate = pd
 	}
 
+	if live := pr.Microformat.PlayerMicroformatRenderer.LiveBroadcastDetails; live != nil && live.IsLiveNow {
+		video.IsLive = true
+	} else {
+		video.WasLive = pr.VideoDetails.IsLiveContent
+	}
+
 	for _, t := range pr.VideoDetails.Thumbnail.Thumbnails {
 		video.Thumbnails = append(video.Thumbnails, Thumbnail{
 			URL:    t.URL,
@@ -146,5 +152,35 @@ func convertFormat(f formatRaw) Format {
 		format.ContentLength, _ = strconv.ParseInt(f.ContentLength, 10, 64)
 	}
 
+	format.HDR = strings.HasSuffix(f.QualityLabel, "HDR")
+	if f.ColorInfo != nil {
+		switch f.ColorInfo.TransferCharacteristics {
+		case "COLOR_TRANSFER_CHARACTERISTICS_SMPTEST2084", "COLOR_TRANSFER_CHARACTERISTICS_ARIB_STD_B67":
+			format.HDR = true
+		}
+	}
+
+	if f.AudioTrack != nil {
+		format.Language, _, _ = strings.Cut(f.AudioTrack.ID, ".")
+	}
+
+	format.InitRange = convertRange(f.InitRange)
+	format.IndexRange = convertRange(f.IndexRange)
+
 	return format
 }
+
+func convertRange(r *rangeRaw) *Range {
+	if r == nil {
+		return nil
+	}
+	start, err := strconv.ParseInt(r.Start, 10, 64)
+	if err != nil {
+		return nil
+	}
+	end, err := strconv.ParseInt(r.End, 10, 64)
+	if err != nil || end < start {
+		return nil
+	}
+	return &Range{Start: start, End: end}
+}
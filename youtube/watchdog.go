@@ -0,0 +1,50 @@
+package youtube
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+type watchdogReader struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	stalled atomic.Bool
+}
+
+func (c *Client) watch(body io.ReadCloser) io.ReadCloser {
+	if c.readTimeout <= 0 {
+		return body
+	}
+	w := &watchdogReader{body: body, timeout: c.readTimeout}
+	w.timer = time.AfterFunc(c.readTimeout, func() {
+		w.stalled.Store(true)
+		_ = w.body.Close()
+	})
+	w.timer.Stop()
+	return w
+}
+
+func (w *watchdogReader) Read(p []byte) (int, error) {
+	if w.stalled.Load() {
+		return 0, w.err()
+	}
+	w.timer.Reset(w.timeout)
+	n, err := w.body.Read(p)
+	w.timer.Stop()
+	if err != nil && w.stalled.Load() {
+		err = w.err()
+	}
+	return n, err
+}
+
+func (w *watchdogReader) err() error {
+	return fmt.Errorf("%w: no data received for %s", ErrStalled, w.timeout)
+}
+
+func (w *watchdogReader) Close() error {
+	w.timer.Stop()
+	return w.body.Close()
+}
@@ -0,0 +1,717 @@
+package youtube
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	ebmlIDHeader             = 0x1A45DFA3
+	ebmlIDSegment            = 0x18538067
+	ebmlIDInfo               = 0x1549A966
+	ebmlIDTimestampScale     = 0x2AD7B1
+	ebmlIDDuration           = 0x4489
+	ebmlIDTracks             = 0x1654AE6B
+	ebmlIDCues               = 0x1C53BB6B
+	ebmlIDCuePoint           = 0xBB
+	ebmlIDCueTime            = 0xB3
+	ebmlIDCueTrackPositions  = 0xB7
+	ebmlIDCueClusterPosition = 0xF1
+	ebmlIDCluster            = 0x1F43B675
+	ebmlIDTimestamp          = 0xE7
+
+	ebmlUnknownSize       = -1
+	defaultTimestampScale = 1000000
+)
+
+var errMalformedWebM = errors.New("malformed webm")
+
+func parseElementHeader(b []byte) (id uint32, size int64, n int, err error) {
+	if len(b) == 0 {
+		return 0, 0, 0, errMalformedWebM
+	}
+	idLen := vintLength(b[0])
+	if idLen == 0 || idLen > 4 || len(b) < idLen {
+		return 0, 0, 0, errMalformedWebM
+	}
+	for _, c := range b[:idLen] {
+		id = id<<8 | uint32(c)
+	}
+
+	rest := b[idLen:]
+	if len(rest) == 0 {
+		return 0, 0, 0, errMalformedWebM
+	}
+	sizeLen := vintLength(rest[0])
+	if sizeLen == 0 || len(rest) < sizeLen {
+		return 0, 0, 0, errMalformedWebM
+	}
+	return id, decodeVintSize(rest[:sizeLen]), idLen + sizeLen, nil
+}
+
+func readElementHeader(r *bufio.Reader) (id uint32, size int64, raw []byte, err error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	idLen := vintLength(first[0])
+	if idLen == 0 || idLen > 4 {
+		return 0, 0, nil, errMalformedWebM
+	}
+	head, err := r.Peek(idLen + 1)
+	if err != nil {
+		return 0, 0, nil, unexpectedEOF(err)
+	}
+	sizeLen := vintLength(head[idLen])
+	if sizeLen == 0 {
+		return 0, 0, nil, errMalformedWebM
+	}
+
+	raw = make([]byte, idLen+sizeLen)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return 0, 0, nil, unexpectedEOF(err)
+	}
+	id, size, _, err = parseElementHeader(raw)
+	return id, size, raw, err
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func vintLength(b byte) int {
+	for i := 0; i < 8; i++ {
+		if b&(0x80>>i) != 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func decodeVintSize(b []byte) int64 {
+	v := uint64(b[0] & (0xff >> len(b)))
+	allOnes := v == uint64(0xff>>len(b))
+	for _, c := range b[1:] {
+		v = v<<8 | uint64(c)
+		allOnes = allOnes && c == 0xff
+	}
+	if allOnes {
+		return ebmlUnknownSize
+	}
+	return int64(v)
+}
+
+func readUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func putUint(b []byte, v uint64) {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+}
+
+func forEachElement(b []byte, fn func(id uint32, body, elem []byte) error) error {
+	for len(b) > 0 {
+		id, size, n, err := parseElementHeader(b)
+		if err != nil {
+			return err
+		}
+		if size == ebmlUnknownSize || int64(len(b)-n) < size {
+			return errMalformedWebM
+		}
+		end := n + int(size)
+		if err := fn(id, b[n:end], b[:end]); err != nil {
+			return err
+		}
+		b = b[end:]
+	}
+	return nil
+}
+
+type cuePoint struct {
+	time     uint64
+	position int64
+}
+
+func parseCues(b []byte) ([]cuePoint, error) {
+	var cues []cuePoint
+	err := forEachElement(b, func(id uint32, body, _ []byte) error {
+		if id != ebmlIDCues {
+			return nil
+		}
+		return forEachElement(body, func(id uint32, body, _ []byte) error {
+			if id != ebmlIDCuePoint {
+				return nil
+			}
+			cue := cuePoint{position: -1}
+			err := forEachElement(body, func(id uint32, body, _ []byte) error {
+				switch id {
+				case ebmlIDCueTime:
+					cue.time = readUint(body)
+				case ebmlIDCueTrackPositions:
+					return forEachElement(body, func(id uint32, body, _ []byte) error {
+						if id == ebmlIDCueClusterPosition && cue.position < 0 {
+							cue.position = int64(readUint(body))
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			if err == nil && cue.position >= 0 {
+				cues = append(cues, cue)
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("%w: no cues", ErrNoIndex)
+	}
+
+	sort.Slice(cues, func(i, j int) bool { return cues[i].position < cues[j].position })
+	unique := cues[:1]
+	for _, c := range cues[1:] {
+		if c.position != unique[len(unique)-1].position {
+			unique = append(unique, c)
+		}
+	}
+	return unique, nil
+}
+
+type webmInit struct {
+	header       []byte
+	info         []byte
+	tracks       []byte
+	segmentStart int64
+	scale        uint64
+	duration     float64
+	durationAt   int
+	durationLen  int
+}
+
+func parseWebMInit(b []byte, offset int64) (*webmInit, error) {
+	id, size, n, err := parseElementHeader(b)
+	if err != nil || id != ebmlIDHeader || size < 0 || int64(len(b)-n) < size {
+		return nil, errMalformedWebM
+	}
+	init := &webmInit{header: b[:n+int(size)], scale: defaultTimestampScale, durationAt: -1}
+	b = b[n+int(size):]
+	offset += int64(n) + size
+
+	id, _, n, err = parseElementHeader(b)
+	if err != nil || id != ebmlIDSegment {
+		return nil, errMalformedWebM
+	}
+	init.segmentStart = offset + int64(n)
+	b = b[n:]
+
+	for len(b) > 0 {
+		id, size, n, err := parseElementHeader(b)
+		if err != nil || size < 0 || int64(len(b)-n) < size {
+			break
+		}
+		elem := b[:n+int(size)]
+		switch id {
+		case ebmlIDInfo:
+			init.info = append([]byte(nil), elem...)
+			if err := init.parseInfo(n); err != nil {
+				return nil, err
+			}
+		case ebmlIDTracks:
+			init.tracks = elem
+		}
+		b = b[n+int(size):]
+	}
+
+	if init.info == nil || init.tracks == nil {
+		return nil, fmt.Errorf("%w: missing segment info or tracks", errMalformedWebM)
+	}
+	return init, nil
+}
+
+func (w *webmInit) parseInfo(headerLen int) error {
+	pos := headerLen
+	return forEachElement(w.info[headerLen:], func(id uint32, body, elem []byte) error {
+		switch id {
+		case ebmlIDTimestampScale:
+			if scale := readUint(body); scale > 0 {
+				w.scale = scale
+			}
+		case ebmlIDDuration:
+			switch len(body) {
+			case 4:
+				w.duration = float64(math.Float32frombits(binary.BigEndian.Uint32(body)))
+			case 8:
+				w.duration = math.Float64frombits(binary.BigEndian.Uint64(body))
+			}
+			w.durationAt = pos + len(elem) - len(body)
+			w.durationLen = len(body)
+		}
+		pos += len(elem)
+		return nil
+	})
+}
+
+func (w *webmInit) toDuration(ts uint64) time.Duration {
+	return time.Duration(ts * w.scale)
+}
+
+func (w *webmInit) build(clip time.Duration) []byte {
+	if w.durationAt >= 0 {
+		ticks := float64(clip) / float64(w.scale)
+		switch w.durationLen {
+		case 4:
+			binary.BigEndian.PutUint32(w.info[w.durationAt:], math.Float32bits(float32(ticks)))
+		case 8:
+			binary.BigEndian.PutUint64(w.info[w.durationAt:], math.Float64bits(ticks))
+		}
+	}
+
+	out := make([]byte, 0, len(w.header)+12+len(w.info)+len(w.tracks))
+	out = append(out, w.header...)
+	out = append(out, 0x18, 0x53, 0x80, 0x67, 0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	out = append(out, w.info...)
+	return append(out, w.tracks...)
+}
+
+func planWebMSection(initData, index []byte, format *Format, contentLength int64, start, end time.Duration) (*sectionPlan, error) {
+	init, err := parseWebMInit(initData, format.InitRange.Start)
+	if err != nil {
+		return nil, err
+	}
+	cues, err := parseCues(index)
+	if err != nil {
+		return nil, err
+	}
+
+	lastByte := contentLength - 1
+	if format.IndexRange.Start > init.segmentStart+cues[len(cues)-1].position {
+		lastByte = format.IndexRange.Start - 1
+	}
+	if lastByte < 0 {
+		return nil, fmt.Errorf("%w: unknown content length", ErrNoIndex)
+	}
+
+	total := time.Duration(init.duration * float64(init.scale))
+	segments := make([]mediaSegment, 0, len(cues))
+	for i, cue := range cues {
+		seg := mediaSegment{
+			start: init.toDuration(cue.time),
+			end:   total,
+			bytes: Range{Start: init.segmentStart + cue.position, End: lastByte},
+		}
+		if i+1 < len(cues) {
+			seg.end = init.toDuration(cues[i+1].time)
+			seg.bytes.End = init.segmentStart + cues[i+1].position - 1
+		}
+		if seg.end <= seg.start {
+			seg.end = seg.start + 1
+		}
+		segments = append(segments, seg)
+	}
+
+	selected, err := selectSegments(segments, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sectionPlan{
+		init:   init.build(selected[len(selected)-1].end - selected[0].start),
+		span:   segmentSpan(selected),
+		origin: selected[0].start,
+		rewrite: func(dst io.Writer, src io.Reader, origin time.Duration) error {
+			return rewriteClusters(dst, src, uint64(origin)/init.scale)
+		},
+	}, nil
+}
+
+func rewriteClusters(dst io.Writer, src io.Reader, shift uint64) error {
+	br := bufio.NewReader(src)
+
+	for {
+		id, size, raw, err := readElementHeader(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if _, err := dst.Write(raw); err != nil {
+			return err
+		}
+		if size == ebmlUnknownSize {
+			_, err := io.Copy(dst, br)
+			return err
+		}
+		if id != ebmlIDCluster {
+			if _, err := io.CopyN(dst, br, size); err != nil {
+				return err
+			}
+			continue
+		}
+
+		childID, childSize, childRaw, err := readElementHeader(br)
+		if err != nil {
+			return err
+		}
+		if _, err := dst.Write(childRaw); err != nil {
+			return err
+		}
+		remaining := size - int64(len(childRaw))
+
+		if childID == ebmlIDTimestamp && childSize > 0 && childSize <= 8 {
+			value := make([]byte, childSize)
+			if _, err := io.ReadFull(br, value); err != nil {
+				return err
+			}
+			ts := readUint(value)
+			putUint(value, ts-min(shift, ts))
+			if _, err := dst.Write(value); err != nil {
+				return err
+			}
+			remaining -= childSize
+		}
+
+		if _, err := io.CopyN(dst, br, remaining); err != nil {
+			return err
+		}
+	}
+}
+
+const (
+	ebmlIDTrackEntry  = 0xAE
+	ebmlIDTrackNumber = 0xD7
+	ebmlIDSimpleBlock = 0xA3
+	ebmlIDBlockGroup  = 0xA0
+	ebmlIDBlock       = 0xA1
+
+	maxElementSize = 64 * 1024 * 1024
+)
+
+func ebmlElement(id uint32, children ...[]byte) []byte {
+	var out []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(out) > 0 {
+			out = append(out, b)
+		}
+	}
+	size := 0
+	for _, c := range children {
+		size += len(c)
+	}
+	sizeField := make([]byte, 8)
+	putUint(sizeField, uint64(size))
+	sizeField[0] = 0x01
+	out = append(out, sizeField...)
+	for _, c := range children {
+		out = append(out, c...)
+	}
+	return out
+}
+
+func peekElementID(r *bufio.Reader) (uint32, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	n := vintLength(first[0])
+	if n == 0 || n > 4 {
+		return 0, errMalformedWebM
+	}
+	b, err := r.Peek(n)
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return uint32(readUint(b)), nil
+}
+
+func readElement(r *bufio.Reader) (uint32, []byte, error) {
+	id, size, raw, err := readElementHeader(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if size == ebmlUnknownSize || size > maxElementSize {
+		return 0, nil, fmt.Errorf("%w: unsupported element size", errMalformedWebM)
+	}
+	elem := make([]byte, len(raw)+int(size))
+	copy(elem, raw)
+	if _, err := io.ReadFull(r, elem[len(raw):]); err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	return id, elem, nil
+}
+
+func readWebMHead(r *bufio.Reader) (*webmInit, error) {
+	id, header, err := readElement(r)
+	if err != nil || id != ebmlIDHeader {
+		return nil, fmt.Errorf("%w: missing EBML header", errMalformedWebM)
+	}
+	init := &webmInit{header: header, scale: defaultTimestampScale, durationAt: -1}
+
+	if id, _, _, err := readElementHeader(r); err != nil || id != ebmlIDSegment {
+		return nil, fmt.Errorf("%w: missing segment", errMalformedWebM)
+	}
+
+	for {
+		id, err := peekElementID(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if id == ebmlIDCluster {
+			break
+		}
+		_, elem, err := readElement(r)
+		if err != nil {
+			return nil, err
+		}
+		switch id {
+		case ebmlIDInfo:
+			init.info = elem
+			_, _, n, _ := parseElementHeader(elem)
+			if err := init.parseInfo(n); err != nil {
+				return nil, err
+			}
+		case ebmlIDTracks:
+			init.tracks = elem
+		}
+	}
+
+	if init.info == nil || init.tracks == nil {
+		return nil, fmt.Errorf("%w: missing segment info or tracks", errMalformedWebM)
+	}
+	return init, nil
+}
+
+func renumberTracks(tracks []byte, next uint64) ([][]byte, map[uint64]uint64, error) {
+	_, _, n, err := parseElementHeader(tracks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries [][]byte
+	numbers := make(map[uint64]uint64)
+	err = forEachElement(tracks[n:], func(id uint32, body, elem []byte) error {
+		if id != ebmlIDTrackEntry {
+			return nil
+		}
+		entry := append([]byte(nil), elem...)
+		offset := len(elem) - len(body)
+		err := forEachElement(entry[offset:], func(id uint32, body, _ []byte) error {
+			if id == ebmlIDTrackNumber {
+				numbers[readUint(body)] = next
+				putUint(body, next)
+				next++
+			}
+			return nil
+		})
+		entries = append(entries, entry)
+		return err
+	})
+	return entries, numbers, err
+}
+
+type clusterStream struct {
+	r         *bufio.Reader
+	tracks    map[uint64]uint64
+	pending   []byte
+	remaining int64
+	time      uint64
+	done      bool
+}
+
+func (s *clusterStream) advance() error {
+	for {
+		id, size, raw, err := readElementHeader(s.r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				s.done = true
+				return nil
+			}
+			return err
+		}
+		if size == ebmlUnknownSize {
+			return fmt.Errorf("%w: unknown-size elements cannot be merged", ErrIncompatibleFormats)
+		}
+		if id != ebmlIDCluster {
+			if _, err := io.CopyN(io.Discard, s.r, size); err != nil {
+				return unexpectedEOF(err)
+			}
+			continue
+		}
+
+		childID, childSize, childRaw, err := readElementHeader(s.r)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if childID != ebmlIDTimestamp || childSize <= 0 || childSize > 8 {
+			return fmt.Errorf("%w: cluster does not start with a timestamp", errMalformedWebM)
+		}
+		value := make([]byte, childSize)
+		if _, err := io.ReadFull(s.r, value); err != nil {
+			return unexpectedEOF(err)
+		}
+
+		s.time = readUint(value)
+		s.pending = append(append(raw, childRaw...), value...)
+		s.remaining = size - int64(len(childRaw)) - childSize
+		return nil
+	}
+}
+
+func (s *clusterStream) emit(w io.Writer) error {
+	if _, err := w.Write(s.pending); err != nil {
+		return err
+	}
+
+	for s.remaining > 0 {
+		id, size, raw, err := readElementHeader(s.r)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if size == ebmlUnknownSize {
+			return errMalformedWebM
+		}
+		s.remaining -= int64(len(raw)) + size
+
+		switch id {
+		case ebmlIDSimpleBlock:
+			if _, err := w.Write(raw); err != nil {
+				return err
+			}
+			first, err := s.r.Peek(1)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			block := make([]byte, vintLength(first[0]))
+			if len(block) == 0 || int64(len(block)) > size {
+				return errMalformedWebM
+			}
+			if _, err := io.ReadFull(s.r, block); err != nil {
+				return unexpectedEOF(err)
+			}
+			s.renumber(block)
+			if _, err := w.Write(block); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(w, s.r, size-int64(len(block))); err != nil {
+				return unexpectedEOF(err)
+			}
+
+		case ebmlIDBlockGroup:
+			if size > maxElementSize {
+				return errMalformedWebM
+			}
+			body := make([]byte, size)
+			if _, err := io.ReadFull(s.r, body); err != nil {
+				return unexpectedEOF(err)
+			}
+			err := forEachElement(body, func(id uint32, block, _ []byte) error {
+				if id == ebmlIDBlock && len(block) > 0 {
+					if n := vintLength(block[0]); n > 0 && n <= len(block) {
+						s.renumber(block[:n])
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(append(raw, body...)); err != nil {
+				return err
+			}
+
+		default:
+			if _, err := w.Write(raw); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(w, s.r, size); err != nil {
+				return unexpectedEOF(err)
+			}
+		}
+	}
+
+	return s.advance()
+}
+
+func (s *clusterStream) renumber(vint []byte) {
+	marker := uint64(1) << (7 * len(vint))
+	number := readUint(vint) &^ marker
+	if mapped, ok := s.tracks[number]; ok && mapped < marker-1 {
+		putUint(vint, mapped|marker)
+	}
+}
+
+func mergeWebM(dst io.Writer, video, audio io.Reader) error {
+	vr, ar := bufio.NewReader(video), bufio.NewReader(audio)
+	vInit, err := readWebMHead(vr)
+	if err != nil {
+		return fmt.Errorf("video: %w", err)
+	}
+	aInit, err := readWebMHead(ar)
+	if err != nil {
+		return fmt.Errorf("audio: %w", err)
+	}
+	if vInit.scale != aInit.scale {
+		return fmt.Errorf("%w: timestamp scales differ", ErrIncompatibleFormats)
+	}
+
+	vEntries, vTracks, err := renumberTracks(vInit.tracks, 1)
+	if err != nil {
+		return err
+	}
+	aEntries, aTracks, err := renumberTracks(aInit.tracks, uint64(len(vEntries)+1))
+	if err != nil {
+		return err
+	}
+
+	head := append([]byte(nil), vInit.header...)
+	head = append(head, 0x18, 0x53, 0x80, 0x67, 0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	head = append(head, vInit.info...)
+	head = append(head, ebmlElement(ebmlIDTracks, append(vEntries, aEntries...)...)...)
+	if _, err := dst.Write(head); err != nil {
+		return err
+	}
+
+	streams := []*clusterStream{
+		{r: vr, tracks: vTracks},
+		{r: ar, tracks: aTracks},
+	}
+	for _, s := range streams {
+		if err := s.advance(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		var next *clusterStream
+		for _, s := range streams {
+			if !s.done && (next == nil || s.time < next.time) {
+				next = s
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		if err := next.emit(dst); err != nil {
+			return err
+		}
+	}
+}